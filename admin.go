@@ -0,0 +1,408 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The name of the logger which the audit records of AdminHandler go to.
+const AdminAuditLoggerName = "logging.admin"
+
+// The result of a level change made through AdminHandler.
+type AdminChange struct {
+	Logger   string `json:"logger,omitempty"`
+	Handler  string `json:"handler,omitempty"`
+	Level    string `json:"level"`
+	Previous string `json:"previous"`
+	TTL      string `json:"ttl,omitempty"`
+}
+
+// Anything with a level which AdminHandler could change, i.e. a logger or
+// a handler.
+type leveler interface {
+	GetLevel() LogLevelType
+	SetLevel(level LogLevelType) error
+}
+
+// A pending revert of a level change with TTL, which is a level override
+// for a logger, or a timer to restore the level for a handler.
+type adminRevert struct {
+	level    LogLevelType
+	timer    *time.Timer
+	override *LevelOverride
+}
+
+// Cancel the pending revert, leaving the level as it is for a handler, or
+// restoring the level under the override for a logger.
+func (self *adminRevert) cancel() {
+	if self.override != nil {
+		self.override.Cancel()
+	} else {
+		self.timer.Stop()
+	}
+}
+
+// An http.Handler to inspect and change the logger hierarchy of a manager
+// at runtime, e.g. to bump a logger to DEBUG during an incident without
+// a redeploy.
+//
+// GET returns the snapshot of the logger hierarchy in JSON, or in text
+// with the query "format=text".
+//
+// PUT or POST changes a level, with the query or form values:
+//     logger   the name of the logger, empty or "root" for the root logger
+//     handler  the name of the handler, instead of logger
+//     level    the name of the new level, e.g. "DEBUG"
+//     ttl      optional duration after which the level reverts, e.g. "10m"
+// A handler is named after its ID when it's created by DictConfig. Only
+// the loggers which already exist could be changed, so that a typo in
+// the name is reported rather than creating a new logger. A change of
+// a logger with TTL is made by a level override of manager, so the level
+// of the logger set in the meantime, e.g. by DictConfig, is kept. A change
+// without TTL sets the level of the logger and cancels all its level
+// overrides, as SignalHandler does, otherwise it would be masked by them.
+//
+// Every change and revert is recorded on the audit logger, regardless of
+// the level of the audit logger.
+type AdminHandler struct {
+	manager *Manager
+	audit   Logger
+	reverts map[string]*adminRevert
+	lock    sync.Mutex
+}
+
+// Initialize an admin handler for the specified manager, with the audit
+// logger named AdminAuditLoggerName in it.
+func NewAdminHandler(manager *Manager) *AdminHandler {
+	return &AdminHandler{
+		manager: manager,
+		audit:   manager.GetLogger(AdminAuditLoggerName),
+		reverts: make(map[string]*adminRevert),
+	}
+}
+
+// Return the audit logger.
+func (self *AdminHandler) GetAuditLogger() Logger {
+	return self.audit
+}
+
+// Set the audit logger. It should be called before serving any request.
+func (self *AdminHandler) SetAuditLogger(logger Logger) {
+	self.audit = logger
+}
+
+func (self *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		self.serveSnapshot(w, r)
+	case http.MethodPut, http.MethodPost:
+		self.serveChange(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (self *AdminHandler) serveSnapshot(
+	w http.ResponseWriter, r *http.Request) {
+
+	snapshot := self.manager.Snapshot()
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, snapshot.String())
+		return
+	}
+	bin, err := snapshot.JSON()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bin)
+}
+
+func (self *AdminHandler) serveChange(
+	w http.ResponseWriter, r *http.Request) {
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loggerName := r.Form.Get("logger")
+	handlerName := r.Form.Get("handler")
+	levelName := strings.ToUpper(r.Form.Get("level"))
+	level, ok := GetNameLevel(levelName)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown level: %s", levelName),
+			http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if value := r.Form.Get("ttl"); len(value) > 0 {
+		var err error
+		ttl, err = time.ParseDuration(value)
+		if (err != nil) || (ttl <= 0) {
+			http.Error(w, fmt.Sprintf("invalid ttl: %s", value),
+				http.StatusBadRequest)
+			return
+		}
+	}
+	change := &AdminChange{
+		Level: GetLevelName(level),
+	}
+	var key string
+	var target leveler
+	if len(handlerName) > 0 {
+		if len(loggerName) > 0 {
+			http.Error(w,
+				"only one of logger and handler should be specified",
+				http.StatusBadRequest)
+			return
+		}
+		handler := self.findHandler(handlerName)
+		if handler == nil {
+			http.Error(w, fmt.Sprintf("unknown handler: %s", handlerName),
+				http.StatusNotFound)
+			return
+		}
+		key, target = "handler:"+handlerName, handler
+		change.Handler = handlerName
+	} else {
+		logger := self.findLogger(loggerName)
+		if logger == nil {
+			http.Error(w, fmt.Sprintf("unknown logger: %s", loggerName),
+				http.StatusNotFound)
+			return
+		}
+		key, target = "logger:"+logger.GetName(), logger
+		change.Logger = logger.GetName()
+	}
+	previous, err := self.change(key, target, level, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	change.Previous = GetLevelName(previous)
+	if ttl > 0 {
+		change.TTL = ttl.String()
+	}
+	self.record("set", change, r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
+
+// Return the logger with the specified name, or the root logger if name is
+// empty or "root". Return nil if there is no such logger in manager, since
+// the logger is not created here.
+func (self *AdminHandler) findLogger(name string) Logger {
+	root := self.manager.GetRoot()
+	if (len(name) == 0) || (name == root.GetName()) {
+		return root
+	}
+	self.manager.lock.Lock()
+	defer self.manager.lock.Unlock()
+	node, ok := self.manager.loggers[name]
+	if !ok || (node.Type() != NodeLogger) {
+		return nil
+	}
+	logger, _ := node.(Logger)
+	return logger
+}
+
+// Return the handler with the specified name, which is registered to
+// the closer of manager or attached to any logger, or nil if there is none.
+func (self *AdminHandler) findHandler(name string) Handler {
+	for _, handler := range self.manager.GetCloser().GetHandlers() {
+		if handler.GetName() == name {
+			return handler
+		}
+	}
+	var found Handler
+	self.manager.Walk(func(_ string, node Node) {
+		logger, ok := node.(Logger)
+		if (found != nil) || !ok {
+			return
+		}
+		for _, handler := range logger.GetHandlers() {
+			if handler.GetName() == name {
+				found = handler
+				return
+			}
+		}
+	})
+	return found
+}
+
+// Set the level of target and schedule a revert if ttl is positive.
+// A pending revert of the same target is cancelled, and the new revert
+// restores the level before the pending one. Return the previous level.
+func (self *AdminHandler) change(
+	key string,
+	target leveler,
+	level LogLevelType,
+	ttl time.Duration) (LogLevelType, error) {
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if logger, ok := target.(Logger); ok {
+		return self.changeLogger(key, logger, level, ttl)
+	}
+	previous := target.GetLevel()
+	if err := target.SetLevel(level); err != nil {
+		return previous, err
+	}
+	revertLevel := previous
+	if pending, ok := self.reverts[key]; ok {
+		pending.cancel()
+		delete(self.reverts, key)
+		revertLevel = pending.level
+	}
+	if ttl > 0 {
+		revert := &adminRevert{
+			level: revertLevel,
+		}
+		revert.timer = time.AfterFunc(ttl, func() {
+			self.revert(key, target, revert)
+		})
+		self.reverts[key] = revert
+	}
+	return previous, nil
+}
+
+// Set the level of logger and cancel its level overrides, or override
+// the level if ttl is positive. The lock should be held.
+func (self *AdminHandler) changeLogger(
+	key string,
+	logger Logger,
+	level LogLevelType,
+	ttl time.Duration) (LogLevelType, error) {
+
+	previous := GetOverriddenLevel(logger)
+	if pending, ok := self.reverts[key]; ok {
+		pending.cancel()
+		delete(self.reverts, key)
+	}
+	if ttl <= 0 {
+		if err := logger.SetLevel(level); err != nil {
+			return previous, err
+		}
+		self.manager.CancelLevelOverrides(logger)
+		return previous, nil
+	}
+	revert := &adminRevert{}
+	override, err := self.manager.pushLevelOverride(
+		logger, level, ttl, func() {
+			self.expire(key, logger, revert)
+		})
+	if err != nil {
+		return previous, err
+	}
+	revert.level = level
+	revert.override = override
+	self.reverts[key] = revert
+	return previous, nil
+}
+
+// Restore the level of the handler when the TTL of a change expires.
+func (self *AdminHandler) revert(
+	key string, target leveler, revert *adminRevert) {
+
+	self.lock.Lock()
+	if self.reverts[key] != revert {
+		// it's superseded by a later change
+		self.lock.Unlock()
+		return
+	}
+	delete(self.reverts, key)
+	previous := target.GetLevel()
+	target.SetLevel(revert.level)
+	self.lock.Unlock()
+	self.recordRevert(key, revert.level, previous)
+}
+
+// Record the revert when the level override of the logger expires.
+func (self *AdminHandler) expire(
+	key string, logger Logger, revert *adminRevert) {
+
+	self.lock.Lock()
+	if self.reverts[key] != revert {
+		self.lock.Unlock()
+		return
+	}
+	delete(self.reverts, key)
+	self.lock.Unlock()
+	self.recordRevert(key, GetOverriddenLevel(logger), revert.level)
+}
+
+func (self *AdminHandler) recordRevert(
+	key string, level, previous LogLevelType) {
+
+	change := &AdminChange{
+		Level:    GetLevelName(level),
+		Previous: GetLevelName(previous),
+	}
+	if strings.HasPrefix(key, "handler:") {
+		change.Handler = strings.TrimPrefix(key, "handler:")
+	} else {
+		change.Logger = strings.TrimPrefix(key, "logger:")
+	}
+	self.record("revert", change, "")
+}
+
+// Stop all pending reverts of handlers, leaving their levels as they are.
+// The level overrides of loggers are left to manager, which still expire
+// but are not recorded any more.
+func (self *AdminHandler) Stop() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for key, revert := range self.reverts {
+		if revert.override == nil {
+			revert.cancel()
+		}
+		delete(self.reverts, key)
+	}
+}
+
+// Record the change on the audit logger.
+func (self *AdminHandler) record(
+	action string, change *AdminChange, remote string) {
+
+	kind, name := "logger", change.Logger
+	if len(change.Handler) > 0 {
+		kind, name = "handler", change.Handler
+	}
+	format := "%s level of %s %s to %s (was %s)"
+	args := []interface{}{action, kind, name, change.Level, change.Previous}
+	if len(change.TTL) > 0 {
+		format += " for %s"
+		args = append(args, change.TTL)
+	}
+	if len(remote) > 0 {
+		format += " by %s"
+		args = append(args, remote)
+	}
+	record := NewLogRecord(
+		self.audit.GetName(),
+		LevelInfo,
+		UnknownCallerInfo.PathName,
+		UnknownCallerInfo.FileName,
+		UnknownCallerInfo.LineNo,
+		UnknownCallerInfo.FuncName,
+		format,
+		true,
+		args)
+	record.Fields = NewFields(
+		"action", action, kind, name,
+		"level", change.Level, "previous", change.Previous)
+	if len(change.TTL) > 0 {
+		record.Fields = record.Fields.Merge(NewFields("ttl", change.TTL))
+	}
+	if len(remote) > 0 {
+		record.Fields = record.Fields.Merge(NewFields("remote", remote))
+	}
+	self.audit.Handle(record)
+}
@@ -0,0 +1,169 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestAdminHandler(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Handlers: map[string]ConfMap{
+			"h": {"class": "NullHandler", "level": "INFO"},
+		},
+		Loggers: map[string]ConfMap{
+			"payments": {"handlers": []interface{}{"h"}},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	audit := NewMockHandler(t)
+	admin := NewAdminHandler(manager)
+	admin.GetAuditLogger().AddHandler(audit)
+	server := httptest.NewServer(admin)
+	defer server.Close()
+	defer admin.Stop()
+
+	// list loggers
+	resp, err := http.Get(server.URL)
+	require.Nil(t, err)
+	var snapshot LoggerSnapshot
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+	resp.Body.Close()
+	require.Equal(t, "WARN", snapshot.Level)
+	resp, err = http.Get(server.URL + "?format=text")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// change the level of a logger without any level
+	logger := manager.GetLogger("payments.db")
+	resp, err = http.PostForm(server.URL, url.Values{
+		"logger": {"payments.db"},
+		"level":  {"debug"},
+	})
+	require.Nil(t, err)
+	var change AdminChange
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&change))
+	resp.Body.Close()
+	require.Equal(t, AdminChange{
+		Logger:   "payments.db",
+		Level:    "DEBUG",
+		Previous: "NOTSET",
+	}, change)
+	require.Equal(t, LevelDebug, logger.GetLevel())
+	record, err := audit.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, AdminAuditLoggerName, record.Name)
+	require.Equal(t, LevelInfo, record.Level)
+	require.True(t, strings.HasPrefix(record.GetMessage(),
+		"set level of logger payments.db to DEBUG (was NOTSET) by "))
+	value, _ := record.Fields.Get("logger")
+	require.Equal(t, "payments.db", value)
+
+	// change the level of a handler with ttl
+	request, err := http.NewRequest(
+		http.MethodPut, server.URL+"?handler=h&level=ERROR&ttl=50ms", nil)
+	require.Nil(t, err)
+	resp, err = http.DefaultClient.Do(request)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	handler := manager.GetLogger("payments").GetHandlers()[0]
+	require.Equal(t, LevelError, handler.GetLevel())
+	record, err = audit.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	value, _ = record.Fields.Get("ttl")
+	require.Equal(t, "50ms", value)
+	record, err = audit.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t,
+		"revert level of handler h to INFO (was ERROR)", record.GetMessage())
+	require.Equal(t, LevelInfo, handler.GetLevel())
+
+	// a later change supersedes the pending revert
+	resp, err = http.PostForm(server.URL, url.Values{
+		"logger": {"root"}, "level": {"INFO"}, "ttl": {"50ms"},
+	})
+	require.Nil(t, err)
+	resp.Body.Close()
+	resp, err = http.PostForm(server.URL, url.Values{
+		"logger": {"root"}, "level": {"DEBUG"}, "ttl": {"100ms"},
+	})
+	require.Nil(t, err)
+	resp.Body.Close()
+	// the change with ttl is made by a level override
+	require.Equal(t, LevelWarn, manager.GetRoot().GetLevel())
+	require.Equal(t, LevelDebug, GetOverriddenLevel(manager.GetRoot()))
+	require.Equal(t, 1, len(manager.GetLevelOverrides()))
+	for i := 0; i < 3; i++ {
+		record, err = audit.GetEmitOnTimeout(time.Second)
+		require.Nil(t, err)
+	}
+	require.Equal(t,
+		"revert level of logger root to WARN (was DEBUG)", record.GetMessage())
+	require.Equal(t, LevelWarn, GetOverriddenLevel(manager.GetRoot()))
+	require.Equal(t, 0, len(manager.GetLevelOverrides()))
+
+	// a change without ttl cancels the level overrides
+	resp, err = http.PostForm(server.URL, url.Values{
+		"logger": {"root"}, "level": {"DEBUG"}, "ttl": {"1h"},
+	})
+	require.Nil(t, err)
+	resp.Body.Close()
+	_, err = manager.PushLevelOverride(
+		manager.GetRoot(), LevelTrace, time.Hour)
+	require.Nil(t, err)
+	resp, err = http.PostForm(server.URL, url.Values{
+		"logger": {"root"}, "level": {"INFO"},
+	})
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, LevelInfo, manager.GetRoot().GetLevel())
+	require.Equal(t, LevelInfo, GetOverriddenLevel(manager.GetRoot()))
+	require.Equal(t, 0, len(manager.GetLevelOverrides()))
+	for i := 0; i < 2; i++ {
+		record, err = audit.GetEmitOnTimeout(time.Second)
+		require.Nil(t, err)
+	}
+	require.Equal(t,
+		"set level of logger root to INFO (was TRACE)",
+		strings.SplitN(record.GetMessage(), " by ", 2)[0])
+
+	// bad requests
+	cases := []struct {
+		values url.Values
+		status int
+	}{
+		{url.Values{"logger": {"a"}, "level": {"NOSUCH"}}, 400},
+		{url.Values{"logger": {"a"}, "level": {"INFO"}, "ttl": {"x"}}, 400},
+		{url.Values{"handler": {"x"}, "level": {"INFO"}}, 404},
+		{url.Values{"logger": {"payments.x"}, "level": {"INFO"}}, 404},
+		// the placeholder of the audit logger
+		{url.Values{"logger": {"logging"}, "level": {"INFO"}}, 404},
+		{url.Values{"handler": {"h"}, "logger": {"a"}, "level": {"INFO"}}, 400},
+	}
+	for _, c := range cases {
+		resp, err = http.PostForm(server.URL, c.values)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, c.status, resp.StatusCode)
+	}
+	manager.lock.Lock()
+	_, ok := manager.loggers["payments.x"]
+	manager.lock.Unlock()
+	require.False(t, ok)
+	request, err = http.NewRequest(http.MethodDelete, server.URL, nil)
+	require.Nil(t, err)
+	resp, err = http.DefaultClient.Do(request)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
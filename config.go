@@ -1,5 +1,3 @@
-// +build !windows
-
 package logging
 
 import (
@@ -8,13 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log/syslog"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
-	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -32,91 +28,155 @@ var (
 		"O_SYNC":   os.O_SYNC,
 		"O_TRUNC":  os.O_TRUNC,
 	}
-	// A map from string description to syslog priority.
-	// The string descriptions are used in configuration file.
-	SyslogNameToPriorities = map[string]syslog.Priority{
-		"LOG_EMERG":    syslog.LOG_EMERG,
-		"LOG_ALERT":    syslog.LOG_ALERT,
-		"LOG_CRIT":     syslog.LOG_CRIT,
-		"LOG_ERR":      syslog.LOG_ERR,
-		"LOG_WARNING":  syslog.LOG_WARNING,
-		"LOG_NOTICE":   syslog.LOG_NOTICE,
-		"LOG_INFO":     syslog.LOG_INFO,
-		"LOG_DEBUG":    syslog.LOG_DEBUG,
-		"LOG_KERN":     syslog.LOG_KERN,
-		"LOG_USER":     syslog.LOG_USER,
-		"LOG_MAIL":     syslog.LOG_MAIL,
-		"LOG_DAEMON":   syslog.LOG_DAEMON,
-		"LOG_AUTH":     syslog.LOG_AUTH,
-		"LOG_SYSLOG":   syslog.LOG_SYSLOG,
-		"LOG_LPR":      syslog.LOG_LPR,
-		"LOG_NEWS":     syslog.LOG_NEWS,
-		"LOG_UUCP":     syslog.LOG_UUCP,
-		"LOG_CRON":     syslog.LOG_CRON,
-		"LOG_AUTHPRIV": syslog.LOG_AUTHPRIV,
-		"LOG_FTP":      syslog.LOG_FTP,
-		"LOG_LOCAL0":   syslog.LOG_LOCAL0,
-		"LOG_LOCAL1":   syslog.LOG_LOCAL1,
-		"LOG_LOCAL2":   syslog.LOG_LOCAL2,
-		"LOG_LOCAL3":   syslog.LOG_LOCAL3,
-		"LOG_LOCAL4":   syslog.LOG_LOCAL4,
-		"LOG_LOCAL5":   syslog.LOG_LOCAL5,
-		"LOG_LOCAL6":   syslog.LOG_LOCAL6,
-		"LOG_LOCAL7":   syslog.LOG_LOCAL7,
-	}
 )
 
-// Apply all configuration in specified file.
+// Apply all configuration in specified file to default manager.
 func ApplyConfigFile(file string) error {
+	return manager.ApplyConfigFile(file)
+}
+
+// Apply all configuration in specified json file to default manager.
+func ApplyJsonConfigFile(file string) error {
+	return manager.ApplyJsonConfigFile(file)
+}
+
+// Apply all configuration in specified yaml file to default manager.
+func ApplyYAMLConfigFile(file string) error {
+	return manager.ApplyYAMLConfigFile(file)
+}
+
+// Apply all configuration in specified file.
+func (self *Manager) ApplyConfigFile(file string) error {
+	conf, err := LoadConfigFile(file)
+	if err != nil {
+		return err
+	}
+	if err := self.DictConfig(conf); err != nil {
+		return err
+	}
+	self.setConfigFile(file, LoadConfigFile)
+	return nil
+}
+
+// Apply all configuration in specified json file.
+func (self *Manager) ApplyJsonConfigFile(file string) error {
+	conf, err := LoadJsonConfigFile(file)
+	if err != nil {
+		return err
+	}
+	if err := self.DictConfig(conf); err != nil {
+		return err
+	}
+	self.setConfigFile(file, LoadJsonConfigFile)
+	return nil
+}
+
+// Apply all configuration in specified yaml file.
+func (self *Manager) ApplyYAMLConfigFile(file string) error {
+	conf, err := LoadYAMLConfigFile(file)
+	if err != nil {
+		return err
+	}
+	if err := self.DictConfig(conf); err != nil {
+		return err
+	}
+	self.setConfigFile(file, LoadYAMLConfigFile)
+	return nil
+}
+
+// Load the configuration in specified file, whose format is determined by
+// the file extension.
+func LoadConfigFile(file string) (*Conf, error) {
 	ext := filepath.Ext(file)
 	switch ext {
 	case ".json":
-		return ApplyJsonConfigFile(file)
+		return LoadJsonConfigFile(file)
 	case ".yml":
 		fallthrough
 	case ".yaml":
-		return ApplyYAMLConfigFile(file)
+		return LoadYAMLConfigFile(file)
 	default:
-		return errors.New(fmt.Sprintf(
+		return nil, errors.New(fmt.Sprintf(
 			"unknown format of the specified file: %s", file))
 	}
 }
 
-// Apply all configuration in specified json file.
-func ApplyJsonConfigFile(file string) error {
+// Load the configuration in specified json file.
+func LoadJsonConfigFile(file string) (*Conf, error) {
 	bin, err := ioutil.ReadFile(file)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	decoder := json.NewDecoder(bytes.NewBuffer(bin))
 	decoder.UseNumber()
 	var conf Conf
 	if err = decoder.Decode(&conf); err != nil {
-		return err
+		return nil, err
 	}
-	return DictConfig(&conf)
+	return &conf, nil
 }
 
-// Apply all configuration in specified yaml file.
-func ApplyYAMLConfigFile(file string) error {
+// Load the configuration in specified yaml file.
+func LoadYAMLConfigFile(file string) (*Conf, error) {
 	bin, err := ioutil.ReadFile(file)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var conf Conf
 	if err = yaml.Unmarshal(bin, &conf); err != nil {
-		return err
+		return nil, err
 	}
-	return DictConfig(&conf)
+	return &conf, nil
 }
 
+// The configuration of a filter, whose type is one of the followings.
+// Type "name" or empty is for NameFilter of name.
+// Type "levelRange" is for LevelRangeFilter of min and max level names.
+// Type "messageRegex" and "nameRegex" are for MessageRegexFilter and
+// NameRegexFilter of pattern and exclude.
+// Type "field" is for FieldFilter of key, value and exclude.
+// Type "rateLimit" is for RateLimitFilter of rate, burst and summaryInterval.
+// Type "sampling" is for SamplingFilter of first, thereafter, interval and
+// summaryInterval.
+// Type "duplicate" is for DuplicateFilter of window interval.
+// Type "expr" is for ExprFilter of expr.
+// All intervals are in milliseconds. Other types could be registered by
+// RegisterFilterFactory().
 type ConfFilter struct {
-	Name string `json:"name"`
+	Type            string      `json:"type" yaml:"type"`
+	Name            string      `json:"name" yaml:"name"`
+	Min             string      `json:"min" yaml:"min"`
+	Max             string      `json:"max" yaml:"max"`
+	Pattern         string      `json:"pattern" yaml:"pattern"`
+	Exclude         bool        `json:"exclude" yaml:"exclude"`
+	Key             string      `json:"key" yaml:"key"`
+	Value           interface{} `json:"value" yaml:"value"`
+	Expr            string      `json:"expr" yaml:"expr"`
+	Rate            float64     `json:"rate" yaml:"rate"`
+	Burst           int         `json:"burst" yaml:"burst"`
+	First           int         `json:"first" yaml:"first"`
+	Thereafter      int         `json:"thereafter" yaml:"thereafter"`
+	Interval        int         `json:"interval" yaml:"interval"`
+	SummaryInterval int         `json:"summaryInterval" yaml:"summaryInterval"`
+	// All keys of the filter in config file, including the ones of
+	// the fields above.
+	Params ConfMap `json:"-" yaml:"-"`
 }
 
+// The configuration of a formatter. Class "StandardFormatter" or empty is
+// for StandardFormatter of format and datefmt. Class "JSONFormatter" is for
+// JSONFormatter of attrs, keys, timeFormat, timePrecision and utc, as in
+// JSONFormatterOptions. Class "LogfmtFormatter" is for LogfmtFormatter of
+// order, keys, timeFormat, timePrecision, utc and omitCaller, as in
+// LogfmtFormatterOptions. Other classes could be registered by
+// RegisterFormatterFactory().
 type ConfFormatter struct {
+	Class      string  `json:"class" yaml:"class"`
 	Format     *string `json:"format" yaml:"format"`
 	DateFormat *string `json:"datefmt" yaml:"datefmt"`
+	// All keys of the formatter in config file, including the ones of
+	// the fields above.
+	Params ConfMap `json:"-" yaml:"-"`
 }
 
 // A map represents configuration of various key and variable length.
@@ -259,6 +319,26 @@ func (self ConfMap) GetUint64(key string) (uint64, error) {
 		value, key, reflect.TypeOf(value)))
 }
 
+func (self ConfMap) GetFloat64(key string) (float64, error) {
+	value, ok := self[key]
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("no config for key: %s", key))
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case json.Number:
+		return v.Float64()
+	}
+	return 0, errors.New(fmt.Sprintf(
+		"value: %#v of key: %s should be of type float64 not type %s",
+		value, key, reflect.TypeOf(value)))
+}
+
 func (self ConfMap) GetString(key string) (string, error) {
 	value, ok := self[key]
 	if !ok {
@@ -273,8 +353,11 @@ func (self ConfMap) GetString(key string) (string, error) {
 }
 
 type Conf struct {
-	Version    int                      `json:"version" yaml:"version"`
-	Root       ConfMap                  `json:"root" yaml:"root"`
+	Version int     `json:"version" yaml:"version"`
+	Root    ConfMap `json:"root" yaml:"root"`
+	// The key could be a rule pattern like "db.*" or "http.**", which
+	// configures all matching loggers, including the ones created later.
+	// The explicit logger names take precedence over patterns.
 	Loggers    map[string]ConfMap       `json:"loggers" yaml:"loggers"`
 	Handlers   map[string]ConfMap       `json:"handlers" yaml:"handlers"`
 	Formatters map[string]ConfFormatter `json:"formatters" yaml:"formatters"`
@@ -316,6 +399,24 @@ func ConfigLevel(m ConfMap, i SetLevelable) error {
 	return nil
 }
 
+// Set the stack level of specified logger if it's specified in the config.
+func ConfigStackLevel(m ConfMap, logger Logger) error {
+	if arg, ok := m["stackLevel"]; ok {
+		levelIn, ok := arg.(string)
+		if !ok {
+			return errors.New(fmt.Sprintf(
+				"stackLevel value: %#v should be of type string", arg))
+		}
+		levelIn = strings.ToUpper(levelIn)
+		level, ok := nameToLevels[levelIn]
+		if !ok {
+			return errors.New(fmt.Sprintf("unknown level: %s", levelIn))
+		}
+		return logger.SetStackLevel(level)
+	}
+	return nil
+}
+
 type SetFormatterable interface {
 	SetFormatter(formatter Formatter)
 }
@@ -380,16 +481,28 @@ func ConfigFilters(m ConfMap, i Filterer, env *ConfEnv) error {
 			if !ok {
 				return errors.New(fmt.Sprintf("unknown filter: %s", name))
 			}
-			i.AddFilter(filter)
+			AttachFilter(i, filter)
 		}
 	}
 	return nil
 }
 
 func ConfigLogger(m ConfMap, logger Logger, isRoot bool, env *ConfEnv) error {
+	return configLogger(m, logger, logger, isRoot, env)
+}
+
+// Configure logger with the logger config m, but add the filters in config
+// to filterer instead of the logger.
+func configLogger(
+	m ConfMap, logger Logger, filterer Filterer, isRoot bool,
+	env *ConfEnv) error {
+
 	if err := ConfigLevel(m, logger); err != nil {
 		return err
 	}
+	if err := ConfigStackLevel(m, logger); err != nil {
+		return err
+	}
 	// propagate setting will not be applicable to root logger
 	if !isRoot {
 		if arg, ok := m["propagate"]; ok {
@@ -404,298 +517,68 @@ func ConfigLogger(m ConfMap, logger Logger, isRoot bool, env *ConfEnv) error {
 	if err := ConfigHandlers(m, logger, env); err != nil {
 		return err
 	}
-	return ConfigFilters(m, logger, env)
+	return ConfigFilters(m, filterer, env)
 }
 
+// Apply the specified configuration to default manager.
 func DictConfig(conf *Conf) error {
-	env := NewConfigEnv()
+	return manager.DictConfig(conf)
+}
+
+// Apply the specified configuration to the logger hierarchy of this manager.
+// All handlers created are registered to the closer of this manager.
+func (self *Manager) DictConfig(conf *Conf) error {
+	self.configLock.Lock()
+	defer self.configLock.Unlock()
+	return self.dictConfig(conf)
+}
+
+func (self *Manager) dictConfig(conf *Conf) error {
 	// check version for compatibility.  Currently only version 1 is supported.
 	if (conf.Version != 0) && (conf.Version != 1) {
 		return errors.New(fmt.Sprintf("unsupport version: %d", conf.Version))
 	}
-	// initialize all filters as specified
-	for name, conf := range conf.Filters {
-		// reject empty name
-		if len(name) == 0 {
-			return errors.New("filter should have non-empty ID")
-		}
-		// reject duplicate name
-		if _, ok := env.filters[name]; ok {
-			return errors.New(fmt.Sprintf("filter id: %s already exists", name))
-		}
-		env.filters[name] = NewNameFilter(conf.Name)
-	}
-	// initialize all formatters as specified
-	for name, conf := range conf.Formatters {
-		if len(name) == 0 {
-			return errors.New("formatter should have non-empty ID")
-		}
-		if _, ok := env.formatters[name]; ok {
-			return errors.New(fmt.Sprintf(
-				"formatter id: %s already exists", name))
-		}
-		var format, dateFormat string
-		if conf.Format != nil {
-			format = *conf.Format
-		} else {
-			format = defaultFormat
-		}
-		if conf.DateFormat != nil {
-			dateFormat = *conf.DateFormat
-		} else {
-			dateFormat = defaultDateFormat
-		}
-		env.formatters[name] = NewStandardFormatter(format, dateFormat)
+	// initialize all filters, formatters and handlers as specified
+	env, err := self.newConfigEnv(conf, nil)
+	if err != nil {
+		return err
 	}
-	// initialize all handlers as specified
-	for name, m := range conf.Handlers {
-		if len(name) == 0 {
-			return errors.New("handler should have non-empty ID")
-		}
-		if _, ok := env.handlers[name]; ok {
-			return errors.New(fmt.Sprintf(
-				"handler id: %s already exists", name))
-		}
-		arg, ok := m["class"]
-		if !ok {
-			return errors.New(fmt.Sprintf(
-				"handler id: %s should specify class", name))
-		}
-		className, ok := arg.(string)
-		if !ok {
-			return errors.New(fmt.Sprintf(
-				"handler id: %s class should be of type string", name))
-		}
-		var handler Handler
-		switch className {
-		case "NullHandler":
-			handler = NewNullHandler()
-		case "MemoryHandler":
-			capacity, err := m.GetUint64("capacity")
-			if err != nil {
-				return err
-			}
-			levelStr, err := m.GetString("level")
-			if err != nil {
-				return err
-			}
-			levelStr = strings.ToUpper(levelStr)
-			level, ok := nameToLevels[levelStr]
-			if !ok {
-				return errors.New(fmt.Sprintf("unknown level: %s", levelStr))
-			}
-			handlerName, err := m.GetString("target")
-			if err != nil {
-				return err
-			}
-			target, ok := env.handlers[handlerName]
-			if !ok {
-				return errors.New(fmt.Sprintf(
-					"target handler id: %s not exists", handlerName))
-			}
-			handler = NewMemoryHandler(capacity, level, target)
-		case "StdoutHandler":
-			handler = NewStdoutHandler()
-		case "FileHandler":
-			filename, err := m.GetString("filename")
-			if err != nil {
-				return err
-			}
-			modeStr, err := m.GetString("mode")
-			if err != nil {
-				return err
-			}
-			mode, ok := FileModeNameToValues[modeStr]
-			if !ok {
-				return errors.New(fmt.Sprintf("unknown file mode: %s", modeStr))
-			}
-			bufferSize, err := m.GetInt("bufferSize")
-			if err != nil {
-				return err
-			}
-			handler, err = NewFileHandler(filename, mode, bufferSize)
-			if err != nil {
-				return err
-			}
-		case "RotatingFileHandler":
-			filepath, err := m.GetString("filepath")
-			if err != nil {
-				return err
-			}
-			modeStr, err := m.GetString("mode")
-			if err != nil {
-				return err
-			}
-			mode, ok := FileModeNameToValues[modeStr]
-			if !ok {
-				return errors.New(fmt.Sprintf("unknown file mode: %s", modeStr))
-			}
-			bufferSize, err := m.GetInt("bufferSize")
-			if err != nil {
-				return err
-			}
-			bufferFlushTimeMS, err := m.GetInt("bufferFlushTime")
-			if err != nil {
-				return err
-			}
-			bufferFlushTime := time.Millisecond * time.Duration(bufferFlushTimeMS)
-			inputChanSize, err := m.GetInt("inputChanSize")
-			if err != nil {
-				return err
-			}
-			maxBytes, err := m.GetUint64("maxBytes")
-			if err != nil {
-				return err
-			}
-			backupCount, err := m.GetUint32("backupCount")
-			if err != nil {
-				return err
-			}
-			handler, err = NewRotatingFileHandler(
-				filepath,
-				mode,
-				bufferSize,
-				bufferFlushTime,
-				inputChanSize,
-				maxBytes,
-				backupCount)
-			if err != nil {
-				return err
-			}
-		case "TimedRotatingFileHandler":
-			filepath, err := m.GetString("filepath")
-			if err != nil {
-				return err
-			}
-			modeStr, err := m.GetString("mode")
-			if err != nil {
-				return err
-			}
-			mode, ok := FileModeNameToValues[modeStr]
-			if !ok {
-				return errors.New(fmt.Sprintf("unknown file mode: %s", modeStr))
-			}
-			bufferSize, err := m.GetInt("bufferSize")
-			if err != nil {
-				return err
-			}
-			bufferFlushTimeMS, err := m.GetInt("bufferFlushTime")
-			if err != nil {
-				return err
-			}
-			bufferFlushTime := time.Millisecond * time.Duration(bufferFlushTimeMS)
-			inputChanSize, err := m.GetInt("inputChanSize")
-			if err != nil {
-				return err
-			}
-			when, err := m.GetString("when")
-			if err != nil {
-				return err
-			}
-			interval, err := m.GetUint32("interval")
-			if err != nil {
-				return err
-			}
-			backupCount, err := m.GetUint32("backupCount")
-			if err != nil {
-				return err
-			}
-			utc, err := m.GetBool("utc")
-			if err != nil {
-				return err
-			}
-			handler, err = NewTimedRotatingFileHandler(
-				filepath,
-				mode,
-				bufferSize,
-				bufferFlushTime,
-				inputChanSize,
-				when,
-				interval,
-				backupCount,
-				utc)
-			if err != nil {
-				return err
-			}
-		case "SyslogHandler":
-			network, err := m.GetString("network")
-			if err != nil {
-				network = ""
-			}
-			raddr, err := m.GetString("raddr")
-			if err != nil {
-				raddr = ""
-			}
-			priorityStr, err := m.GetString("priority")
-			if err != nil {
-				return err
-			}
-			priority, ok := SyslogNameToPriorities[priorityStr]
-			if !ok {
-				return errors.New(fmt.Sprintf(
-					"unknown priority: %s", priorityStr))
-			}
-			tag, err := m.GetString("tag")
-			if err != nil {
-				return err
-			}
-			if network != "" && raddr != "" {
-				handler, err = NewSyslogHandlerToAddr(network, raddr, priority, tag)
-			} else {
-				handler, err = NewSyslogHandler(priority, tag)
-			}
-			if err != nil {
-				return err
-			}
-		case "DatagramHandler":
-			host, err := m.GetString("host")
-			if err != nil {
-				return err
-			}
-			port, err := m.GetUint16("port")
-			if err != nil {
-				return err
-			}
-			handler = NewDatagramHandler(host, port)
-		case "SocketHandler":
-			host, err := m.GetString("host")
-			if err != nil {
-				return err
-			}
-			port, err := m.GetUint16("port")
-			if err != nil {
-				return err
-			}
-			handler = NewSocketHandler(host, port)
-		default:
-			return errors.New(fmt.Sprintf("unsupported class name: %s", className))
-		}
-		if err := ConfigLevel(m, handler); err != nil {
-			return err
-		}
-		if err := ConfigFormatters(m, handler, env); err != nil {
+	// set root logger
+	if len(conf.Root) > 0 {
+		if err := ConfigLogger(conf.Root, self.root, true, env); err != nil {
 			return err
 		}
-		if err := ConfigFilters(m, handler, env); err != nil {
+	}
+	// add rules for all logger patterns in order of the patterns
+	patterns := configRulePatterns(conf)
+	rules := make([]*LoggerRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rule, err := newConfigRule(pattern, conf.Loggers[pattern], env)
+		if err != nil {
 			return err
 		}
-		env.handlers[name] = handler
-	}
-	// set root logger
-	if len(conf.Root) > 0 {
-		if err := ConfigLogger(conf.Root, root, true, env); err != nil {
+		if err := self.AddRule(rule); err != nil {
 			return err
 		}
+		rules = append(rules, rule)
 	}
 	// initialize all loggers as specified
 	for name, m := range conf.Loggers {
 		if len(name) == 0 {
 			return errors.New("logger should have non-empty ID")
 		}
-		logger := GetLogger(name)
+		if IsRulePattern(name) {
+			continue
+		}
+		logger := self.GetLogger(name)
 		if err := ConfigLogger(m, logger, false, env); err != nil {
 			return err
 		}
 	}
+	self.applied = &appliedConfig{
+		conf:  conf,
+		env:   env,
+		rules: rules,
+	}
 	return nil
 }
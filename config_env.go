@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// The configuration applied to manager, along with the objects created for
+// it, so that a new configuration could be applied incrementally.
+type appliedConfig struct {
+	conf  *Conf
+	env   *ConfEnv
+	rules []*LoggerRule
+}
+
+// Anything could be configured as a handler by config, e.g. a handler.
+type configurableHandler interface {
+	SetLevelable
+	SetFormatterable
+	Filterer
+}
+
+// Set the level, formatter and filters of the handler by config m.
+func configHandler(m ConfMap, handler configurableHandler, env *ConfEnv) error {
+	if err := ConfigLevel(m, handler); err != nil {
+		return err
+	}
+	if err := ConfigFormatters(m, handler, env); err != nil {
+		return err
+	}
+	return ConfigFilters(m, handler, env)
+}
+
+// A scratch to validate the level, formatter and filters in the config of
+// a handler without touching any handler or filter.
+type scratchHandler struct {
+	*StandardFilterer
+}
+
+func (self *scratchHandler) SetLevel(level LogLevelType) error {
+	return nil
+}
+
+func (self *scratchHandler) SetFormatter(formatter Formatter) {
+}
+
+// Validate the logger config m on a scratch logger, without touching any
+// logger or filter. The filters are added to a plain filterer rather than
+// the scratch, otherwise the emitters of the filters, which may be in use,
+// would be pointed to the scratch.
+func validateConfigLogger(
+	name string, m ConfMap, isRoot bool, env *ConfEnv) error {
+
+	scratch := NewStandardLogger(name, LevelNotset)
+	return configLogger(m, scratch, NewStandardFilterer(), isRoot, env)
+}
+
+// Return the handler IDs in config, in the order to be created, so that
+// the target of a handler, e.g. MemoryHandler, is created before it.
+func orderConfigHandlers(handlers map[string]ConfMap) []string {
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]string, 0, len(names))
+	visited := make(map[string]bool, len(names))
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		if target, err := handlers[name].GetString("target"); err == nil {
+			if _, ok := handlers[target]; ok {
+				visit(target)
+			}
+		}
+		result = append(result, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return result
+}
+
+// Create all filters, formatters and handlers specified in conf into a new
+// config env. The objects in reuse are taken rather than created for the
+// same IDs, and the settings of the reused handlers are validated but left
+// untouched. If any error occurs, the handlers created so far are closed.
+func (self *Manager) newConfigEnv(
+	conf *Conf, reuse *ConfEnv) (*ConfEnv, error) {
+
+	if reuse == nil {
+		reuse = NewConfigEnv()
+	}
+	env := NewConfigEnv()
+	// initialize all filters as specified
+	for name, conf := range conf.Filters {
+		// reject empty name
+		if len(name) == 0 {
+			return nil, errors.New("filter should have non-empty ID")
+		}
+		if filter, ok := reuse.filters[name]; ok {
+			env.filters[name] = filter
+			continue
+		}
+		filter, err := newConfigFilter(conf, env)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf(
+				"filter id: %s: %s", name, err.Error()))
+		}
+		env.filters[name] = filter
+	}
+	// initialize all formatters as specified
+	for name, conf := range conf.Formatters {
+		if len(name) == 0 {
+			return nil, errors.New("formatter should have non-empty ID")
+		}
+		if formatter, ok := reuse.formatters[name]; ok {
+			env.formatters[name] = formatter
+			continue
+		}
+		formatter, err := newConfigFormatter(conf, env)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf(
+				"formatter id: %s: %s", name, err.Error()))
+		}
+		env.formatters[name] = formatter
+	}
+	// initialize all handlers as specified
+	var created []Handler
+	fail := func(err error) (*ConfEnv, error) {
+		for _, handler := range created {
+			self.closer.RemoveHandler(handler)
+			handler.Close()
+		}
+		return nil, err
+	}
+	for _, name := range orderConfigHandlers(conf.Handlers) {
+		m := conf.Handlers[name]
+		if len(name) == 0 {
+			return fail(errors.New("handler should have non-empty ID"))
+		}
+		if handler, ok := reuse.handlers[name]; ok {
+			scratch := &scratchHandler{NewStandardFilterer()}
+			if err := configHandler(m, scratch, env); err != nil {
+				return fail(err)
+			}
+			env.handlers[name] = handler
+			continue
+		}
+		arg, ok := m["class"]
+		if !ok {
+			return fail(errors.New(fmt.Sprintf(
+				"handler id: %s should specify class", name)))
+		}
+		className, ok := arg.(string)
+		if !ok {
+			return fail(errors.New(fmt.Sprintf(
+				"handler id: %s class should be of type string", name)))
+		}
+		handler, err := newConfigHandler(className, m, env)
+		if err != nil {
+			return fail(err)
+		}
+		self.AdoptHandler(handler)
+		created = append(created, handler)
+		handler.SetName(name)
+		if err := configHandler(m, handler, env); err != nil {
+			return fail(err)
+		}
+		env.handlers[name] = handler
+	}
+	return env, nil
+}
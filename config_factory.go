@@ -0,0 +1,856 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A function to create a handler of some class from its config m, which
+// could refer to the filters, formatters and handlers created before it in
+// env, e.g. the target of MemoryHandler. The level, formatter and filters
+// in config are set by DictConfig() after the handler is created, so there
+// is no need to set them in factory.
+type HandlerFactory func(m ConfMap, env *ConfEnv) (Handler, error)
+
+// A function to create a formatter of some class from its config m.
+type FormatterFactory func(m ConfMap, env *ConfEnv) (Formatter, error)
+
+// A function to create a filter of some type from its config m.
+type FilterFactory func(m ConfMap, env *ConfEnv) (Filter, error)
+
+var (
+	handlerFactories   = make(map[string]HandlerFactory)
+	formatterFactories = make(map[string]FormatterFactory)
+	filterFactories    = make(map[string]FilterFactory)
+	configWriters      = map[string]io.Writer{
+		"stdout": os.Stdout,
+		"stderr": os.Stderr,
+	}
+	factoryLock sync.RWMutex
+)
+
+// Register the factory of handlers of the class, so that handlers of the
+// class could be created from config by DictConfig(), e.g. in init() of
+// the package providing the handler. Any factory registered before for
+// the class, including the one of a built-in handler, is replaced.
+func RegisterHandlerFactory(class string, factory HandlerFactory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+	handlerFactories[class] = factory
+}
+
+// Register the factory of formatters of the class.
+// See RegisterHandlerFactory() for details.
+func RegisterFormatterFactory(class string, factory FormatterFactory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+	formatterFactories[class] = factory
+}
+
+// Register the factory of filters of the type.
+// See RegisterHandlerFactory() for details.
+func RegisterFilterFactory(filterType string, factory FilterFactory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+	filterFactories[filterType] = factory
+}
+
+// Register the writer by name, so that it could be referred to by
+// WriterHandler in config, e.g. {"class": "WriterHandler", "writer": name}.
+// The writers "stdout" and "stderr" are registered already.
+func RegisterConfigWriter(name string, writer io.Writer) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+	configWriters[name] = writer
+}
+
+// Return the handler of the ID created in this env.
+func (self *ConfEnv) GetHandler(id string) (Handler, bool) {
+	handler, ok := self.handlers[id]
+	return handler, ok
+}
+
+// Return the formatter of the ID created in this env.
+func (self *ConfEnv) GetFormatter(id string) (Formatter, bool) {
+	formatter, ok := self.formatters[id]
+	return formatter, ok
+}
+
+// Return the filter of the ID created in this env.
+func (self *ConfEnv) GetFilter(id string) (Filter, bool) {
+	filter, ok := self.filters[id]
+	return filter, ok
+}
+
+// Create the handler of the class by its registered factory.
+func newConfigHandler(
+	className string, m ConfMap, env *ConfEnv) (Handler, error) {
+
+	factoryLock.RLock()
+	factory, ok := handlerFactories[className]
+	factoryLock.RUnlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(
+			"unsupported class name: %s", className))
+	}
+	return factory(m, env)
+}
+
+// Create the formatter specified in config by its registered factory.
+func newConfigFormatter(conf ConfFormatter, env *ConfEnv) (Formatter, error) {
+	class := conf.Class
+	if len(class) == 0 {
+		class = "StandardFormatter"
+	}
+	factoryLock.RLock()
+	factory, ok := formatterFactories[class]
+	factoryLock.RUnlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unsupported class name: %s", class))
+	}
+	return factory(conf.GetConfMap(), env)
+}
+
+// Create the filter specified in config by its registered factory.
+func newConfigFilter(conf ConfFilter, env *ConfEnv) (Filter, error) {
+	filterType := conf.Type
+	if len(filterType) == 0 {
+		filterType = "name"
+	}
+	factoryLock.RLock()
+	factory, ok := filterFactories[filterType]
+	factoryLock.RUnlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unknown type: %s", filterType))
+	}
+	return factory(conf.GetConfMap(), env)
+}
+
+// Return all keys of the formatter in config as a map, which is passed to
+// the formatter factory.
+func (self ConfFormatter) GetConfMap() ConfMap {
+	return confStructToMap(self, self.Params)
+}
+
+func (self *ConfFormatter) UnmarshalJSON(data []byte) error {
+	type plain ConfFormatter
+	if err := json.Unmarshal(data, (*plain)(self)); err != nil {
+		return err
+	}
+	return unmarshalJSONParams(data, &self.Params)
+}
+
+func (self *ConfFormatter) UnmarshalYAML(
+	unmarshal func(interface{}) error) error {
+
+	type plain ConfFormatter
+	if err := unmarshal((*plain)(self)); err != nil {
+		return err
+	}
+	return unmarshal(&self.Params)
+}
+
+// Return all keys of the filter in config as a map, which is passed to
+// the filter factory.
+func (self ConfFilter) GetConfMap() ConfMap {
+	return confStructToMap(self, self.Params)
+}
+
+func (self *ConfFilter) UnmarshalJSON(data []byte) error {
+	type plain ConfFilter
+	if err := json.Unmarshal(data, (*plain)(self)); err != nil {
+		return err
+	}
+	return unmarshalJSONParams(data, &self.Params)
+}
+
+func (self *ConfFilter) UnmarshalYAML(
+	unmarshal func(interface{}) error) error {
+
+	type plain ConfFilter
+	if err := unmarshal((*plain)(self)); err != nil {
+		return err
+	}
+	return unmarshal(&self.Params)
+}
+
+// Unmarshal all keys in the JSON object into params, with numbers in
+// json.Number as the config file loaded by LoadJsonConfigFile().
+func unmarshalJSONParams(data []byte, params *ConfMap) error {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	return decoder.Decode(params)
+}
+
+// Return a map of params along with the non-zero fields of the config
+// struct v, which override the params of the same keys. The untyped fields
+// are taken from params if any, since the numbers in params are decoded in
+// json.Number rather than float64, e.g. the value of a field filter.
+func confStructToMap(v interface{}, params ConfMap) ConfMap {
+	m := make(ConfMap, len(params))
+	for key, value := range params {
+		m[key] = value
+	}
+	value := reflect.ValueOf(v)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if (len(key) == 0) || (key == "-") {
+			continue
+		}
+		fieldValue := value.Field(i)
+		if fieldValue.IsZero() {
+			continue
+		}
+		if _, ok := params[key]; ok &&
+			(fieldValue.Kind() == reflect.Interface) {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Ptr {
+			fieldValue = fieldValue.Elem()
+		}
+		m[key] = fieldValue.Interface()
+	}
+	return m
+}
+
+// Return the value of the key as getter does, or zero if there is no such
+// key.
+func getOptionalInt(m ConfMap, key string) (int, error) {
+	if _, ok := m[key]; !ok {
+		return 0, nil
+	}
+	return m.GetInt(key)
+}
+
+func getOptionalString(m ConfMap, key string) (string, error) {
+	if _, ok := m[key]; !ok {
+		return "", nil
+	}
+	return m.GetString(key)
+}
+
+func getOptionalBool(m ConfMap, key string) (bool, error) {
+	if _, ok := m[key]; !ok {
+		return false, nil
+	}
+	return m.GetBool(key)
+}
+
+// Return the duration of the key in milliseconds, or zero if there is no
+// such key.
+func getOptionalMS(m ConfMap, key string) (time.Duration, error) {
+	ms, err := getOptionalInt(m, key)
+	if err != nil {
+		return 0, err
+	}
+	return time.Millisecond * time.Duration(ms), nil
+}
+
+// Return the level of the name of the key, or LevelNotset if there is no
+// such key.
+func getOptionalLevel(m ConfMap, key string) (LogLevelType, error) {
+	name, err := getOptionalString(m, key)
+	if (err != nil) || (len(name) == 0) {
+		return LevelNotset, err
+	}
+	level, ok := GetNameLevel(strings.ToUpper(name))
+	if !ok {
+		return LevelNotset, errors.New(fmt.Sprintf("unknown level: %s", name))
+	}
+	return level, nil
+}
+
+// Return the file mode of the name of key "mode".
+func getConfigFileMode(m ConfMap) (int, error) {
+	modeStr, err := m.GetString("mode")
+	if err != nil {
+		return 0, err
+	}
+	mode, ok := FileModeNameToValues[modeStr]
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("unknown file mode: %s", modeStr))
+	}
+	return mode, nil
+}
+
+func init() {
+	RegisterHandlerFactory("NullHandler", newConfigNullHandler)
+	RegisterHandlerFactory("MemoryHandler", newConfigMemoryHandler)
+	RegisterHandlerFactory("StdoutHandler", newConfigStdoutHandler)
+	RegisterHandlerFactory("StderrHandler", newConfigStderrHandler)
+	RegisterHandlerFactory("WriterHandler", newConfigWriterHandler)
+	RegisterHandlerFactory("ConsoleHandler", newConfigConsoleHandler)
+	RegisterHandlerFactory("FileHandler", newConfigFileHandler)
+	RegisterHandlerFactory(
+		"RotatingFileHandler", newConfigRotatingFileHandler)
+	RegisterHandlerFactory(
+		"TimedRotatingFileHandler", newConfigTimedRotatingFileHandler)
+	RegisterHandlerFactory("DatagramHandler", newConfigDatagramHandler)
+	RegisterHandlerFactory("SocketHandler", newConfigSocketHandler)
+
+	RegisterFormatterFactory("StandardFormatter", newConfigStandardFormatter)
+	RegisterFormatterFactory("JSONFormatter", newConfigJSONFormatter)
+	RegisterFormatterFactory("LogfmtFormatter", newConfigLogfmtFormatter)
+
+	RegisterFilterFactory("name", newConfigNameFilter)
+	RegisterFilterFactory("levelRange", newConfigLevelRangeFilter)
+	RegisterFilterFactory("messageRegex", newConfigMessageRegexFilter)
+	RegisterFilterFactory("nameRegex", newConfigNameRegexFilter)
+	RegisterFilterFactory("field", newConfigFieldFilter)
+	RegisterFilterFactory("rateLimit", newConfigRateLimitFilter)
+	RegisterFilterFactory("sampling", newConfigSamplingFilter)
+	RegisterFilterFactory("duplicate", newConfigDuplicateFilter)
+	RegisterFilterFactory("expr", newConfigExprFilter)
+}
+
+func newConfigNullHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	return NewNullHandler(), nil
+}
+
+func newConfigMemoryHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	capacity, err := m.GetUint64("capacity")
+	if err != nil {
+		return nil, err
+	}
+	levelStr, err := m.GetString("level")
+	if err != nil {
+		return nil, err
+	}
+	levelStr = strings.ToUpper(levelStr)
+	level, ok := GetNameLevel(levelStr)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unknown level: %s", levelStr))
+	}
+	handlerName, err := m.GetString("target")
+	if err != nil {
+		return nil, err
+	}
+	target, ok := env.GetHandler(handlerName)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(
+			"target handler id: %s not exists", handlerName))
+	}
+	return NewMemoryHandler(capacity, level, target), nil
+}
+
+func newConfigStdoutHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	return NewStdoutHandler(), nil
+}
+
+func newConfigStderrHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	return NewStderrHandler(), nil
+}
+
+func newConfigWriterHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	name, err := m.GetString("writer")
+	if err != nil {
+		return nil, err
+	}
+	factoryLock.RLock()
+	writer, ok := configWriters[name]
+	factoryLock.RUnlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unknown writer: %s", name))
+	}
+	bufferSize, err := getOptionalInt(m, "bufferSize")
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterHandler(writer, bufferSize), nil
+}
+
+func newConfigConsoleHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	stream, err := getOptionalString(m, "stream")
+	if err != nil {
+		return nil, err
+	}
+	var file *os.File
+	switch stream {
+	case "", "stderr":
+		file = os.Stderr
+	case "stdout":
+		file = os.Stdout
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown stream: %s", stream))
+	}
+	palette, err := getOptionalStringMap(m, "palette")
+	if err != nil {
+		return nil, err
+	}
+	levels := make(map[LogLevelType]string, len(palette))
+	for name, color := range palette {
+		level, ok := GetNameLevel(strings.ToUpper(name))
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("unknown level: %s", name))
+		}
+		if _, err := parseConsoleColor(color); err != nil {
+			return nil, err
+		}
+		levels[level] = color
+	}
+	nameColor, err := getOptionalString(m, "nameColor")
+	if err != nil {
+		return nil, err
+	}
+	if len(nameColor) > 0 {
+		if _, err := parseConsoleColor(nameColor); err != nil {
+			return nil, err
+		}
+	}
+	colorName, err := getOptionalBool(m, "colorName")
+	if err != nil {
+		return nil, err
+	}
+	var color *bool
+	if value, ok := m["color"]; ok && (value != "auto") {
+		c, err := m.GetBool("color")
+		if err != nil {
+			return nil, err
+		}
+		color = &c
+	}
+	handler := NewConsoleHandler(file)
+	for level, c := range levels {
+		handler.SetLevelColor(level, c)
+	}
+	if len(nameColor) > 0 {
+		handler.SetNameColor(nameColor)
+	}
+	handler.SetColorName(colorName)
+	if color != nil {
+		handler.SetColor(*color)
+	}
+	return handler, nil
+}
+
+func newConfigFileHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	filename, err := m.GetString("filename")
+	if err != nil {
+		return nil, err
+	}
+	mode, err := getConfigFileMode(m)
+	if err != nil {
+		return nil, err
+	}
+	bufferSize, err := m.GetInt("bufferSize")
+	if err != nil {
+		return nil, err
+	}
+	handler, err := NewFileHandler(filename, mode, bufferSize)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func newConfigRotatingFileHandler(
+	m ConfMap, env *ConfEnv) (Handler, error) {
+
+	filepath, err := m.GetString("filepath")
+	if err != nil {
+		return nil, err
+	}
+	mode, err := getConfigFileMode(m)
+	if err != nil {
+		return nil, err
+	}
+	bufferSize, err := m.GetInt("bufferSize")
+	if err != nil {
+		return nil, err
+	}
+	bufferFlushTimeMS, err := m.GetInt("bufferFlushTime")
+	if err != nil {
+		return nil, err
+	}
+	bufferFlushTime := time.Millisecond * time.Duration(bufferFlushTimeMS)
+	inputChanSize, err := m.GetInt("inputChanSize")
+	if err != nil {
+		return nil, err
+	}
+	maxBytes, err := m.GetUint64("maxBytes")
+	if err != nil {
+		return nil, err
+	}
+	backupCount, err := m.GetUint32("backupCount")
+	if err != nil {
+		return nil, err
+	}
+	handler, err := NewRotatingFileHandler(
+		filepath,
+		mode,
+		bufferSize,
+		bufferFlushTime,
+		inputChanSize,
+		maxBytes,
+		backupCount)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func newConfigTimedRotatingFileHandler(
+	m ConfMap, env *ConfEnv) (Handler, error) {
+
+	filepath, err := m.GetString("filepath")
+	if err != nil {
+		return nil, err
+	}
+	mode, err := getConfigFileMode(m)
+	if err != nil {
+		return nil, err
+	}
+	bufferSize, err := m.GetInt("bufferSize")
+	if err != nil {
+		return nil, err
+	}
+	bufferFlushTimeMS, err := m.GetInt("bufferFlushTime")
+	if err != nil {
+		return nil, err
+	}
+	bufferFlushTime := time.Millisecond * time.Duration(bufferFlushTimeMS)
+	inputChanSize, err := m.GetInt("inputChanSize")
+	if err != nil {
+		return nil, err
+	}
+	when, err := m.GetString("when")
+	if err != nil {
+		return nil, err
+	}
+	interval, err := m.GetUint32("interval")
+	if err != nil {
+		return nil, err
+	}
+	backupCount, err := m.GetUint32("backupCount")
+	if err != nil {
+		return nil, err
+	}
+	utc, err := m.GetBool("utc")
+	if err != nil {
+		return nil, err
+	}
+	handler, err := NewTimedRotatingFileHandler(
+		filepath,
+		mode,
+		bufferSize,
+		bufferFlushTime,
+		inputChanSize,
+		when,
+		interval,
+		backupCount,
+		utc)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func newConfigDatagramHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	host, err := m.GetString("host")
+	if err != nil {
+		return nil, err
+	}
+	port, err := m.GetUint16("port")
+	if err != nil {
+		return nil, err
+	}
+	return NewDatagramHandler(host, port), nil
+}
+
+func newConfigSocketHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	host, err := m.GetString("host")
+	if err != nil {
+		return nil, err
+	}
+	port, err := m.GetUint16("port")
+	if err != nil {
+		return nil, err
+	}
+	return NewSocketHandler(host, port), nil
+}
+
+func newConfigStandardFormatter(m ConfMap, env *ConfEnv) (Formatter, error) {
+	format, dateFormat := defaultFormat, defaultDateFormat
+	if _, ok := m["format"]; ok {
+		var err error
+		if format, err = m.GetString("format"); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := m["datefmt"]; ok {
+		var err error
+		if dateFormat, err = m.GetString("datefmt"); err != nil {
+			return nil, err
+		}
+	}
+	return NewStandardFormatter(format, dateFormat), nil
+}
+
+// Return the strings of the list of the key, or nil if there is no such key.
+func getOptionalStrings(m ConfMap, key string) ([]string, error) {
+	value, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(
+			"%s value: %#v should be of type string slice", key, value))
+	}
+	result := make([]string, 0, len(list))
+	for _, v := range list {
+		str, ok := v.(string)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf(
+				"%#v in %s should be of type string", v, key))
+		}
+		result = append(result, str)
+	}
+	return result, nil
+}
+
+// Return the map of strings of the key, or nil if there is no such key.
+func getOptionalStringMap(m ConfMap, key string) (map[string]string, error) {
+	value, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	add := func(k, v interface{}) error {
+		kStr, ok1 := k.(string)
+		vStr, ok2 := v.(string)
+		if !ok1 || !ok2 {
+			return errors.New(fmt.Sprintf(
+				"%#v: %#v in %s should be of type string", k, v, key))
+		}
+		result[kStr] = vStr
+		return nil
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, v := range v {
+			if err := add(k, v); err != nil {
+				return nil, err
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, v := range v {
+			if err := add(k, v); err != nil {
+				return nil, err
+			}
+		}
+	case ConfMap:
+		for k, v := range v {
+			if err := add(k, v); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, errors.New(fmt.Sprintf(
+			"%s value: %#v should be of type string map", key, value))
+	}
+	return result, nil
+}
+
+func newConfigJSONFormatter(m ConfMap, env *ConfEnv) (Formatter, error) {
+	var opts JSONFormatterOptions
+	var err error
+	if opts.Attrs, err = getOptionalStrings(m, "attrs"); err != nil {
+		return nil, err
+	}
+	if opts.Keys, err = getOptionalStringMap(m, "keys"); err != nil {
+		return nil, err
+	}
+	if opts.TimeFormat, err = getOptionalString(m, "timeFormat"); err != nil {
+		return nil, err
+	}
+	opts.TimePrecision, err = getOptionalString(m, "timePrecision")
+	if err != nil {
+		return nil, err
+	}
+	if opts.UTC, err = getOptionalBool(m, "utc"); err != nil {
+		return nil, err
+	}
+	formatter, err := NewJSONFormatter(&opts)
+	if err != nil {
+		return nil, err
+	}
+	return formatter, nil
+}
+
+func newConfigLogfmtFormatter(m ConfMap, env *ConfEnv) (Formatter, error) {
+	var opts LogfmtFormatterOptions
+	var err error
+	if opts.Order, err = getOptionalStrings(m, "order"); err != nil {
+		return nil, err
+	}
+	if opts.Keys, err = getOptionalStringMap(m, "keys"); err != nil {
+		return nil, err
+	}
+	if opts.TimeFormat, err = getOptionalString(m, "timeFormat"); err != nil {
+		return nil, err
+	}
+	opts.TimePrecision, err = getOptionalString(m, "timePrecision")
+	if err != nil {
+		return nil, err
+	}
+	if opts.UTC, err = getOptionalBool(m, "utc"); err != nil {
+		return nil, err
+	}
+	if opts.OmitCaller, err = getOptionalBool(m, "omitCaller"); err != nil {
+		return nil, err
+	}
+	formatter, err := NewLogfmtFormatter(&opts)
+	if err != nil {
+		return nil, err
+	}
+	return formatter, nil
+}
+
+func newConfigNameFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	name, err := getOptionalString(m, "name")
+	if err != nil {
+		return nil, err
+	}
+	return NewNameFilter(name), nil
+}
+
+func newConfigLevelRangeFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	min, err := getOptionalLevel(m, "min")
+	if err != nil {
+		return nil, err
+	}
+	max, err := getOptionalLevel(m, "max")
+	if err != nil {
+		return nil, err
+	}
+	if (max != LevelNotset) && (max < min) {
+		return nil, errors.New(fmt.Sprintf(
+			"max level: %s is lower than min level: %s", max, min))
+	}
+	return NewLevelRangeFilter(min, max), nil
+}
+
+// Return the regular expression of key "pattern" and the flag of key
+// "exclude".
+func getConfigRegex(m ConfMap) (*regexp.Regexp, bool, error) {
+	pattern, err := getOptionalString(m, "pattern")
+	if err != nil {
+		return nil, false, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false, err
+	}
+	exclude, err := getOptionalBool(m, "exclude")
+	if err != nil {
+		return nil, false, err
+	}
+	return re, exclude, nil
+}
+
+func newConfigMessageRegexFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	re, exclude, err := getConfigRegex(m)
+	if err != nil {
+		return nil, err
+	}
+	return NewMessageRegexFilter(re, exclude), nil
+}
+
+func newConfigNameRegexFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	re, exclude, err := getConfigRegex(m)
+	if err != nil {
+		return nil, err
+	}
+	return NewNameRegexFilter(re, exclude), nil
+}
+
+func newConfigFieldFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	key, err := getOptionalString(m, "key")
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, errors.New("key should be non-empty")
+	}
+	exclude, err := getOptionalBool(m, "exclude")
+	if err != nil {
+		return nil, err
+	}
+	return NewFieldFilter(key, m["value"], exclude), nil
+}
+
+func newConfigRateLimitFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	rate, err := m.GetFloat64("rate")
+	if err != nil {
+		return nil, err
+	}
+	if rate <= 0 {
+		return nil, errors.New("rate should be positive")
+	}
+	burst, err := getOptionalInt(m, "burst")
+	if err != nil {
+		return nil, err
+	}
+	summaryInterval, err := getOptionalMS(m, "summaryInterval")
+	if err != nil {
+		return nil, err
+	}
+	return NewRateLimitFilter(rate, burst, summaryInterval), nil
+}
+
+func newConfigSamplingFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	first, err := getOptionalInt(m, "first")
+	if err != nil {
+		return nil, err
+	}
+	thereafter, err := getOptionalInt(m, "thereafter")
+	if err != nil {
+		return nil, err
+	}
+	interval, err := getOptionalMS(m, "interval")
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("interval should be positive")
+	}
+	summaryInterval, err := getOptionalMS(m, "summaryInterval")
+	if err != nil {
+		return nil, err
+	}
+	return NewSamplingFilter(
+		first, thereafter, interval, summaryInterval), nil
+}
+
+func newConfigDuplicateFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	interval, err := getOptionalMS(m, "interval")
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("interval should be positive")
+	}
+	return NewDuplicateFilter(interval), nil
+}
+
+func newConfigExprFilter(m ConfMap, env *ConfEnv) (Filter, error) {
+	expr, err := getOptionalString(m, "expr")
+	if err != nil {
+		return nil, err
+	}
+	filter, err := NewExprFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
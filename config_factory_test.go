@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+type prefixFormatter struct {
+	prefix string
+}
+
+func (self *prefixFormatter) Format(record *LogRecord) string {
+	return self.prefix + record.GetMessage()
+}
+
+func init() {
+	RegisterHandlerFactory("testHandler",
+		func(m ConfMap, env *ConfEnv) (Handler, error) {
+			targetName, err := m.GetString("target")
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := env.GetHandler(targetName); !ok {
+				return nil, errors.New("no target")
+			}
+			handler := NewNullHandler()
+			handler.SetName(targetName)
+			return handler, nil
+		})
+	RegisterFormatterFactory("testFormatter",
+		func(m ConfMap, env *ConfEnv) (Formatter, error) {
+			prefix, err := m.GetString("prefix")
+			if err != nil {
+				return nil, err
+			}
+			return &prefixFormatter{prefix}, nil
+		})
+	RegisterFilterFactory("testFilter",
+		func(m ConfMap, env *ConfEnv) (Filter, error) {
+			names, ok := m["names"].([]interface{})
+			if !ok {
+				return nil, errors.New("names should be a list")
+			}
+			return NewNameFilter(names[0].(string)), nil
+		})
+}
+
+func TestConfigFactories(t *testing.T) {
+	yamlConf := `
+handlers:
+    h1:
+        class: NullHandler
+    h2:
+        class: testHandler
+        target: h1
+        formatter: f
+formatters:
+    f:
+        class: testFormatter
+        prefix: "> "
+filters:
+    fl:
+        type: testFilter
+        names: [a.b]
+    rate:
+        type: rateLimit
+        rate: 0.5
+        burst: 2
+loggers:
+    a:
+        handlers: [h2]
+        filters: [fl, rate]
+`
+	jsonConf := `{
+    "handlers": {
+        "h1": {"class": "NullHandler"},
+        "h2": {"class": "testHandler", "target": "h1", "formatter": "f"}
+    },
+    "formatters": {
+        "f": {"class": "testFormatter", "prefix": "> "}
+    },
+    "filters": {
+        "fl": {"type": "testFilter", "names": ["a.b"]},
+        "rate": {"type": "rateLimit", "rate": 0.5, "burst": 2}
+    },
+    "loggers": {
+        "a": {"handlers": ["h2"], "filters": ["fl", "rate"]}
+    }
+}`
+	var conf1, conf2 Conf
+	require.Nil(t, yaml.Unmarshal([]byte(yamlConf), &conf1))
+	decoder := json.NewDecoder(strings.NewReader(jsonConf))
+	decoder.UseNumber()
+	require.Nil(t, decoder.Decode(&conf2))
+	for _, conf := range []*Conf{&conf1, &conf2} {
+		manager := NewManager(NewRootLogger(LevelWarn))
+		require.Nil(t, manager.DictConfig(conf))
+		logger := manager.GetLogger("a")
+		handlers := logger.GetHandlers()
+		require.Equal(t, 1, len(handlers))
+		require.Equal(t, "h2", handlers[0].GetName())
+		record := NewLogRecord("a.b", LevelInfo, "", "", 0, "",
+			"%s", true, []interface{}{"message"})
+		require.Equal(t, "> message", handlers[0].Format(record))
+		filters := logger.(*StandardLogger).GetFilters()
+		require.Equal(t, 2, filters.Len())
+		require.Equal(t, `NameFilter("a.b")`,
+			filters.Front().Value.(*NameFilter).String())
+		require.Equal(t, "RateLimitFilter(rate=0.5, burst=2)",
+			filters.Back().Value.(*RateLimitFilter).String())
+		manager.Shutdown()
+	}
+
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf1.Handlers["h2"]["target"] = "h3"
+	require.NotNil(t, manager.DictConfig(&conf1))
+	conf1.Handlers["h2"]["class"] = "unknownHandler"
+	require.NotNil(t, manager.DictConfig(&conf1))
+}
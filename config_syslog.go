@@ -0,0 +1,82 @@
+// +build !windows
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log/syslog"
+)
+
+var (
+	// A map from string description to syslog priority.
+	// The string descriptions are used in configuration file.
+	SyslogNameToPriorities = map[string]syslog.Priority{
+		"LOG_EMERG":    syslog.LOG_EMERG,
+		"LOG_ALERT":    syslog.LOG_ALERT,
+		"LOG_CRIT":     syslog.LOG_CRIT,
+		"LOG_ERR":      syslog.LOG_ERR,
+		"LOG_WARNING":  syslog.LOG_WARNING,
+		"LOG_NOTICE":   syslog.LOG_NOTICE,
+		"LOG_INFO":     syslog.LOG_INFO,
+		"LOG_DEBUG":    syslog.LOG_DEBUG,
+		"LOG_KERN":     syslog.LOG_KERN,
+		"LOG_USER":     syslog.LOG_USER,
+		"LOG_MAIL":     syslog.LOG_MAIL,
+		"LOG_DAEMON":   syslog.LOG_DAEMON,
+		"LOG_AUTH":     syslog.LOG_AUTH,
+		"LOG_SYSLOG":   syslog.LOG_SYSLOG,
+		"LOG_LPR":      syslog.LOG_LPR,
+		"LOG_NEWS":     syslog.LOG_NEWS,
+		"LOG_UUCP":     syslog.LOG_UUCP,
+		"LOG_CRON":     syslog.LOG_CRON,
+		"LOG_AUTHPRIV": syslog.LOG_AUTHPRIV,
+		"LOG_FTP":      syslog.LOG_FTP,
+		"LOG_LOCAL0":   syslog.LOG_LOCAL0,
+		"LOG_LOCAL1":   syslog.LOG_LOCAL1,
+		"LOG_LOCAL2":   syslog.LOG_LOCAL2,
+		"LOG_LOCAL3":   syslog.LOG_LOCAL3,
+		"LOG_LOCAL4":   syslog.LOG_LOCAL4,
+		"LOG_LOCAL5":   syslog.LOG_LOCAL5,
+		"LOG_LOCAL6":   syslog.LOG_LOCAL6,
+		"LOG_LOCAL7":   syslog.LOG_LOCAL7,
+	}
+)
+
+func init() {
+	RegisterHandlerFactory("SyslogHandler", newConfigSyslogHandler)
+}
+
+func newConfigSyslogHandler(m ConfMap, env *ConfEnv) (Handler, error) {
+	network, err := m.GetString("network")
+	if err != nil {
+		network = ""
+	}
+	raddr, err := m.GetString("raddr")
+	if err != nil {
+		raddr = ""
+	}
+	priorityStr, err := m.GetString("priority")
+	if err != nil {
+		return nil, err
+	}
+	priority, ok := SyslogNameToPriorities[priorityStr]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(
+			"unknown priority: %s", priorityStr))
+	}
+	tag, err := m.GetString("tag")
+	if err != nil {
+		return nil, err
+	}
+	var handler *SyslogHandler
+	if network != "" && raddr != "" {
+		handler, err = NewSyslogHandlerToAddr(network, raddr, priority, tag)
+	} else {
+		handler, err = NewSyslogHandler(priority, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
@@ -0,0 +1,461 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// The keys of handler config which could be updated in place, rather than
+// replacing the handler.
+var inPlaceHandlerKeys = []string{"level", "formatter", "filters"}
+
+// Report whether the handler configs are the same except the keys which
+// could be updated in place. The level of MemoryHandler is its flush level
+// as well, so any change of it requires a new handler.
+func sameHandlerConf(a, b ConfMap) bool {
+	strip := func(m ConfMap) ConfMap {
+		result := make(ConfMap, len(m))
+		for key, value := range m {
+			result[key] = value
+		}
+		if class, _ := m.GetString("class"); class == "MemoryHandler" {
+			delete(result, "formatter")
+			delete(result, "filters")
+			return result
+		}
+		for _, key := range inPlaceHandlerKeys {
+			delete(result, key)
+		}
+		return result
+	}
+	return reflect.DeepEqual(strip(a), strip(b))
+}
+
+// Return the config maps in conf which apply to the logger with the
+// specified name, in the order to be applied.
+func configMapsFor(conf *Conf, name string, isRoot bool) []ConfMap {
+	var result []ConfMap
+	if isRoot {
+		if len(conf.Root) > 0 {
+			result = append(result, conf.Root)
+		}
+		return result
+	}
+	for _, pattern := range configRulePatterns(conf) {
+		if MatchRulePattern(pattern, name) {
+			result = append(result, conf.Loggers[pattern])
+		}
+	}
+	if m, ok := conf.Loggers[name]; ok && !IsRulePattern(name) {
+		result = append(result, m)
+	}
+	return result
+}
+
+func hasConfigKey(maps []ConfMap, key string) bool {
+	for _, m := range maps {
+		if _, ok := m[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Add the desired filters to filterer, and remove the filters in old which
+// are not desired any more.
+func syncConfigFilters(filterer Filterer, desired *ListSet, old *ConfEnv) {
+	for e := desired.Front(); e != nil; e = e.Next() {
+		filter, _ := e.Value.(Filter)
+		AttachFilter(filterer, filter)
+	}
+	for _, filter := range old.filters {
+		if !desired.SetContains(filter) {
+			filterer.RemoveFilter(filter)
+		}
+	}
+}
+
+// Update the level, formatter and filters of a reused handler in place.
+func updateConfigHandler(
+	handler Handler, oldM, m ConfMap, old, env *ConfEnv) {
+
+	if _, ok := m["level"]; ok {
+		ConfigLevel(m, handler)
+	} else if _, ok := oldM["level"]; ok {
+		handler.SetLevel(LevelNotset)
+	}
+	if _, ok := m["formatter"]; ok {
+		ConfigFormatters(m, handler, env)
+	} else if _, ok := oldM["formatter"]; ok {
+		handler.SetFormatter(nil)
+	}
+	scratch := NewStandardFilterer()
+	ConfigFilters(m, scratch, env)
+	syncConfigFilters(handler, scratch.GetFilters(), old)
+}
+
+// Reconfigure the logger from the old config to the new one. The settings
+// specified only by the old config are reset to their defaults, and only
+// the handlers and filters of the old config are removed, so that those
+// added by program are kept.
+func reconfigLogger(logger Logger, isRoot bool, old, applied *appliedConfig) {
+	name := logger.GetName()
+	oldMaps := configMapsFor(old.conf, name, isRoot)
+	maps := configMapsFor(applied.conf, name, isRoot)
+	if (len(oldMaps) == 0) && (len(maps) == 0) {
+		return
+	}
+	// all configs have been validated
+	scratch := NewStandardLogger(name, LevelNotset)
+	filters := NewStandardFilterer()
+	for _, m := range maps {
+		configLogger(m, scratch, filters, isRoot, applied.env)
+	}
+	if hasConfigKey(maps, "level") {
+		logger.SetLevel(scratch.GetLevel())
+	} else if hasConfigKey(oldMaps, "level") && !isRoot {
+		logger.SetLevel(LevelNotset)
+	}
+	if hasConfigKey(maps, "stackLevel") {
+		logger.SetStackLevel(scratch.GetStackLevel())
+	} else if hasConfigKey(oldMaps, "stackLevel") {
+		logger.SetStackLevel(LevelNotset)
+	}
+	if !isRoot {
+		if hasConfigKey(maps, "propagate") {
+			logger.SetPropagate(scratch.GetPropagate())
+		} else if hasConfigKey(oldMaps, "propagate") {
+			logger.SetPropagate(true)
+		}
+	}
+	desired := scratch.GetHandlers()
+	for _, handler := range desired {
+		logger.AddHandler(handler)
+	}
+	for _, handler := range old.env.handlers {
+		found := false
+		for _, h := range desired {
+			if h == handler {
+				found = true
+				break
+			}
+		}
+		if !found {
+			logger.RemoveHandler(handler)
+		}
+	}
+	syncConfigFilters(logger, filters.GetFilters(), old.env)
+}
+
+// Close the handlers in env which are not kept in keep, the dependent ones
+// before their targets.
+func (self *Manager) closeConfigHandlers(conf *Conf, env, keep *ConfEnv) {
+	names := orderConfigHandlers(conf.Handlers)
+	for i := len(names) - 1; i >= 0; i-- {
+		handler, ok := env.handlers[names[i]]
+		if !ok || (keep.handlers[names[i]] == handler) {
+			continue
+		}
+		self.closer.RemoveHandler(handler)
+		handler.Close()
+	}
+}
+
+// Apply the specified configuration to the logger hierarchy of this manager
+// incrementally, against the one applied by DictConfig() or ReloadConfig()
+// before. It's the same as DictConfig() if there is none.
+//
+// The filters, formatters and handlers of the same IDs and config are kept.
+// The levels, formatters and filters of the handlers are updated in place,
+// while a handler with any other change is replaced by a new one. The loggers
+// are reconfigured to the new config, and the handlers removed are closed.
+//
+// The whole config is validated and all new handlers are created before any
+// change is made, so that the working config is kept if any error occurs.
+func (self *Manager) ReloadConfig(conf *Conf) error {
+	self.configLock.Lock()
+	defer self.configLock.Unlock()
+	old := self.applied
+	if old == nil {
+		return self.dictConfig(conf)
+	}
+	if (conf.Version != 0) && (conf.Version != 1) {
+		return errors.New(fmt.Sprintf("unsupport version: %d", conf.Version))
+	}
+	// find out the objects to be kept
+	reuse := NewConfigEnv()
+	for name, c := range conf.Filters {
+		if oldC, ok := old.conf.Filters[name]; ok && reflect.DeepEqual(oldC, c) {
+			reuse.filters[name] = old.env.filters[name]
+		}
+	}
+	for name, c := range conf.Formatters {
+		if oldC, ok := old.conf.Formatters[name]; ok &&
+			reflect.DeepEqual(oldC, c) {
+			reuse.formatters[name] = old.env.formatters[name]
+		}
+	}
+	for name, m := range conf.Handlers {
+		if oldM, ok := old.conf.Handlers[name]; ok && sameHandlerConf(oldM, m) {
+			reuse.handlers[name] = old.env.handlers[name]
+		}
+	}
+	// a handler whose target is replaced should be replaced as well
+	for changed := true; changed; {
+		changed = false
+		for name := range reuse.handlers {
+			target, err := conf.Handlers[name].GetString("target")
+			if err != nil {
+				continue
+			}
+			if _, ok := reuse.handlers[target]; !ok {
+				delete(reuse.handlers, name)
+				changed = true
+			}
+		}
+	}
+	env, err := self.newConfigEnv(conf, reuse)
+	if err != nil {
+		return err
+	}
+	// validate all logger configs
+	fail := func(err error) error {
+		self.closeConfigHandlers(conf, env, reuse)
+		return err
+	}
+	if len(conf.Root) > 0 {
+		if err := validateConfigLogger("root", conf.Root, true, env); err != nil {
+			return fail(err)
+		}
+	}
+	patterns := configRulePatterns(conf)
+	rules := make([]*LoggerRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		if err := ValidateRulePattern(pattern); err != nil {
+			return fail(err)
+		}
+		rule, err := newConfigRule(pattern, conf.Loggers[pattern], env)
+		if err != nil {
+			return fail(err)
+		}
+		rules = append(rules, rule)
+	}
+	for name, m := range conf.Loggers {
+		if len(name) == 0 {
+			return fail(errors.New("logger should have non-empty ID"))
+		}
+		if IsRulePattern(name) {
+			continue
+		}
+		if err := validateConfigLogger(name, m, false, env); err != nil {
+			return fail(err)
+		}
+	}
+	// apply the changes
+	applied := &appliedConfig{
+		conf:  conf,
+		env:   env,
+		rules: rules,
+	}
+	for name, handler := range reuse.handlers {
+		updateConfigHandler(
+			handler, old.conf.Handlers[name], conf.Handlers[name], old.env, env)
+	}
+	self.replaceRules(old.rules, rules)
+	for name := range conf.Loggers {
+		if !IsRulePattern(name) {
+			self.GetLogger(name)
+		}
+	}
+	self.Walk(func(_ string, node Node) {
+		if logger, ok := node.(Logger); ok {
+			reconfigLogger(logger, node == self.root, old, applied)
+		}
+	})
+	self.closeConfigHandlers(old.conf, old.env, env)
+	self.applied = applied
+	return nil
+}
+
+// Remember the config file applied last time, along with the function
+// to load it.
+func (self *Manager) setConfigFile(
+	file string, load func(file string) (*Conf, error)) {
+
+	self.configLock.Lock()
+	defer self.configLock.Unlock()
+	self.configFile, self.configLoad = file, load
+}
+
+// Return the config file applied last time by ApplyConfigFile() and alike,
+// or empty string if there is none.
+func (self *Manager) GetConfigFile() string {
+	self.configLock.Lock()
+	defer self.configLock.Unlock()
+	return self.configFile
+}
+
+// Load the config file applied last time again, and apply it by
+// ReloadConfig().
+func (self *Manager) ReapplyConfigFile() error {
+	self.configLock.Lock()
+	file, load := self.configFile, self.configLoad
+	self.configLock.Unlock()
+	if load == nil {
+		return errors.New("no config file applied")
+	}
+	conf, err := load(file)
+	if err != nil {
+		return err
+	}
+	return self.ReloadConfig(conf)
+}
+
+// Options for WatchConfigFile().
+type WatchOptions struct {
+	// The interval to check the modification of config file. Polling is
+	// disabled if it's zero.
+	PollInterval time.Duration
+	// Whether to reload config file on SIGHUP.
+	ReloadOnSIGHUP bool
+	// Called with the error if it fails to reload config file, while
+	// the working config is kept. The error is logged on the root logger
+	// if it's nil.
+	OnError func(err error)
+	// Called with the new config after config file is reloaded.
+	OnReload func(conf *Conf)
+}
+
+// A watcher which reloads config file by ReloadConfig() on modification
+// or SIGHUP.
+type ConfigWatcher struct {
+	manager   *Manager
+	file      string
+	opts      WatchOptions
+	modTime   time.Time
+	size      int64
+	stopChan  chan struct{}
+	waitGroup sync.WaitGroup
+	lock      sync.Mutex
+}
+
+// Apply config file to default manager, and reload it on modification or
+// SIGHUP. See Manager.WatchConfigFile() for details.
+func WatchConfigFile(file string, opts *WatchOptions) (*ConfigWatcher, error) {
+	return manager.WatchConfigFile(file, opts)
+}
+
+// Apply the specified config file, and reload it by ReloadConfig() when
+// it's modified, which is checked by polling, or on SIGHUP, as specified
+// in opts. If opts is nil, it polls config file every 5 seconds.
+func (self *Manager) WatchConfigFile(
+	file string, opts *WatchOptions) (*ConfigWatcher, error) {
+
+	if opts == nil {
+		opts = &WatchOptions{
+			PollInterval: 5 * time.Second,
+		}
+	}
+	object := &ConfigWatcher{
+		manager:  self,
+		file:     file,
+		opts:     *opts,
+		stopChan: make(chan struct{}),
+	}
+	if err := object.load(); err != nil {
+		return nil, err
+	}
+	object.waitGroup.Add(1)
+	go object.loop()
+	return object, nil
+}
+
+// Load and apply config file, and keep its modification time and size.
+// They are kept even if it fails, so that an invalid config file is not
+// loaded again until it's modified.
+func (self *ConfigWatcher) load() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	info, err := os.Stat(self.file)
+	if err != nil {
+		return err
+	}
+	self.modTime, self.size = info.ModTime(), info.Size()
+	conf, err := LoadConfigFile(self.file)
+	if err != nil {
+		return err
+	}
+	if err := self.manager.ReloadConfig(conf); err != nil {
+		return err
+	}
+	self.manager.setConfigFile(self.file, LoadConfigFile)
+	if self.opts.OnReload != nil {
+		self.opts.OnReload(conf)
+	}
+	return nil
+}
+
+// Reload config file immediately. The error is reported as well.
+func (self *ConfigWatcher) Reload() error {
+	err := self.load()
+	if err != nil {
+		if self.opts.OnError != nil {
+			self.opts.OnError(err)
+		} else {
+			self.manager.GetRoot().ErrorErr(
+				err, "fail to reload config file: %s", self.file)
+		}
+	}
+	return err
+}
+
+// Report whether config file is modified since it's loaded last time.
+func (self *ConfigWatcher) modified() bool {
+	info, err := os.Stat(self.file)
+	if err != nil {
+		return false
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return !info.ModTime().Equal(self.modTime) || (info.Size() != self.size)
+}
+
+func (self *ConfigWatcher) loop() {
+	defer self.waitGroup.Done()
+	var tickChan <-chan time.Time
+	if self.opts.PollInterval > 0 {
+		ticker := time.NewTicker(self.opts.PollInterval)
+		defer ticker.Stop()
+		tickChan = ticker.C
+	}
+	var signalChan chan os.Signal
+	if self.opts.ReloadOnSIGHUP {
+		signalChan = make(chan os.Signal, 1)
+		signal.Notify(signalChan, syscall.SIGHUP)
+		defer signal.Stop(signalChan)
+	}
+	for {
+		select {
+		case <-tickChan:
+			if self.modified() {
+				self.Reload()
+			}
+		case <-signalChan:
+			self.Reload()
+		case <-self.stopChan:
+			return
+		}
+	}
+}
+
+// Stop watching config file. The config applied is kept.
+func (self *ConfigWatcher) Stop() {
+	close(self.stopChan)
+	self.waitGroup.Wait()
+}
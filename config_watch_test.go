@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func containsHandler(handlers []Handler, handler Handler) bool {
+	for _, h := range handlers {
+		if h == handler {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReloadConfig(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	format := "%(message)s"
+	conf := &Conf{
+		Formatters: map[string]ConfFormatter{
+			"f": {Format: &format},
+		},
+		Filters: map[string]ConfFilter{
+			"fl": {Name: "a"},
+		},
+		Handlers: map[string]ConfMap{
+			"h1": {"class": "NullHandler", "level": "INFO", "formatter": "f"},
+			"h2": {"class": "NullHandler"},
+		},
+		Loggers: map[string]ConfMap{
+			"a": {
+				"level":    "DEBUG",
+				"handlers": []interface{}{"h1", "h2"},
+				"filters":  []interface{}{"fl"},
+			},
+			"b.*": {"level": "ERROR", "handlers": []interface{}{"h2"}},
+		},
+	}
+	require.Nil(t, manager.ReloadConfig(conf))
+	a := manager.GetLogger("a")
+	bx := manager.GetLogger("b.x")
+	require.Equal(t, 2, len(a.GetHandlers()))
+	h1, h2 := a.GetHandlers()[0], a.GetHandlers()[1]
+	require.Equal(t, "h1", h1.GetName())
+	require.Equal(t, []Handler{h2}, bx.GetHandlers())
+	extra := NewNullHandler()
+	a.AddHandler(extra)
+	registered := len(manager.GetCloser().GetHandlers())
+
+	// an invalid config is rejected as a whole
+	bad := &Conf{
+		Handlers: map[string]ConfMap{
+			"h3": {"class": "NullHandler"},
+			"h4": {"class": "NoSuchHandler"},
+		},
+	}
+	require.NotNil(t, manager.ReloadConfig(bad))
+	bad = &Conf{
+		Handlers: conf.Handlers,
+		Loggers: map[string]ConfMap{
+			"a": {"level": "NOSUCHLEVEL"},
+		},
+	}
+	require.NotNil(t, manager.ReloadConfig(bad))
+	require.Equal(t, registered, len(manager.GetCloser().GetHandlers()))
+	require.Equal(t, []Handler{h1, h2, extra}, a.GetHandlers())
+	require.Equal(t, LevelDebug, a.GetLevel())
+
+	conf = &Conf{
+		Formatters: conf.Formatters,
+		Handlers: map[string]ConfMap{
+			"h1": {"class": "NullHandler", "level": "ERROR"},
+			"h3": {"class": "NullHandler"},
+		},
+		Loggers: map[string]ConfMap{
+			"a": {"handlers": []interface{}{"h1", "h3"}},
+			"c": {"level": "INFO", "propagate": false},
+		},
+	}
+	require.Nil(t, manager.ReloadConfig(conf))
+	handlers := a.GetHandlers()
+	require.Equal(t, 3, len(handlers))
+	require.True(t, containsHandler(handlers, h1))
+	require.True(t, containsHandler(handlers, extra))
+	require.False(t, containsHandler(handlers, h2))
+	h3 := handlers[2]
+	require.Equal(t, "h3", h3.GetName())
+	// h1 is updated in place, and h2 is closed
+	require.Equal(t, LevelError, h1.GetLevel())
+	require.Equal(t, 0, h1.(*NullHandler).GetFilters().Len())
+	cached := manager.GetCloser().GetHandlers()
+	require.False(t, containsHandler(cached, h2))
+	require.True(t, containsHandler(cached, h3))
+	require.Equal(t, LevelNotset, a.GetLevel())
+	require.Equal(t, 0, a.(*StandardLogger).GetFilters().Len())
+	require.Equal(t, LevelNotset, bx.GetLevel())
+	require.Equal(t, 0, len(bx.GetHandlers()))
+	require.Equal(t, LevelNotset, manager.GetLogger("b.y").GetLevel())
+	c := manager.GetLogger("c")
+	require.Equal(t, LevelInfo, c.GetLevel())
+	require.False(t, c.GetPropagate())
+}
+
+func TestReloadConfig_Emitters(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelInfo))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Filters: map[string]ConfFilter{
+			"rate": {Type: "rateLimit", Rate: 0.001, Burst: 1,
+				SummaryInterval: 100},
+		},
+		Loggers: map[string]ConfMap{
+			"a": {"filters": []interface{}{"rate"}},
+		},
+	}
+	require.Nil(t, manager.ReloadConfig(conf))
+	a := manager.GetLogger("a")
+	handler := NewMockHandler(t)
+	a.AddHandler(handler)
+
+	// the filter is validated for root and rule, but still emits to a
+	// after the config is rejected
+	bad := &Conf{
+		Filters: conf.Filters,
+		Root:    ConfMap{"filters": []interface{}{"rate"}},
+		Loggers: map[string]ConfMap{
+			"a":   {"filters": []interface{}{"rate"}},
+			"b.*": {"filters": []interface{}{"rate"}},
+			"c":   {"level": "NOSUCHLEVEL"},
+		},
+	}
+	require.NotNil(t, manager.ReloadConfig(bad))
+	for i := 0; i < 3; i++ {
+		a.Infof("message %d", i)
+	}
+	record, err := handler.GetEmitOnTimeout(time.Millisecond * 10)
+	require.Nil(t, err)
+	require.Equal(t, "message 0", record.GetMessage())
+	record, err = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t,
+		"2 records suppressed by rate limit in last 100ms, the last one: "+
+			"message 2",
+		record.GetMessage())
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.json")
+	write := func(content string) {
+		require.Nil(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	write(`{"loggers": {"w": {"level": "INFO"}}}`)
+
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	reloadChan := make(chan *Conf, 10)
+	errorChan := make(chan error, 10)
+	watcher, err := manager.WatchConfigFile(file, &WatchOptions{
+		PollInterval: 10 * time.Millisecond,
+		OnReload: func(conf *Conf) {
+			reloadChan <- conf
+		},
+		OnError: func(err error) {
+			errorChan <- err
+		},
+	})
+	require.Nil(t, err)
+	defer watcher.Stop()
+	<-reloadChan
+	logger := manager.GetLogger("w")
+	require.Equal(t, LevelInfo, logger.GetLevel())
+
+	write(`{"loggers": {"w": {"level": "DEBUG"}}}`)
+	select {
+	case <-reloadChan:
+	case <-time.After(time.Second):
+		require.True(t, false, "config file is not reloaded")
+	}
+	require.Equal(t, LevelDebug, logger.GetLevel())
+
+	write(`{"loggers": {"w": {"level": "NOSUCHLEVEL"}}}`)
+	select {
+	case err := <-errorChan:
+		require.NotNil(t, err)
+	case <-time.After(time.Second):
+		require.True(t, false, "invalid config file is not reported")
+	}
+	require.Equal(t, LevelDebug, logger.GetLevel())
+	// the invalid config file is reported only once
+	select {
+	case err := <-errorChan:
+		require.True(t, false, "invalid config file is reported again: "+
+			err.Error())
+	case <-time.After(100 * time.Millisecond):
+	}
+	write(`{"loggers": {"w": {"level": "INFO"}}}`)
+	select {
+	case <-reloadChan:
+	case <-time.After(time.Second):
+		require.True(t, false, "fixed config file is not reloaded")
+	}
+	require.Equal(t, LevelInfo, logger.GetLevel())
+
+	_, err = manager.WatchConfigFile(filepath.Join(dir, "none.json"), nil)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"time"
+)
+
+// Function type of pulling fields, such as trace ID or tenant ID, out of
+// a context passed to Logger.Ctx().
+type ContextExtractor func(ctx context.Context) Fields
+
+type namedContextExtractor struct {
+	name      string
+	extractor ContextExtractor
+}
+
+// Return a context extractor which stamps the value of ctxKey in context
+// as field key, if the value exists.
+func NewContextValueExtractor(key string, ctxKey interface{}) ContextExtractor {
+	return func(ctx context.Context) Fields {
+		value := ctx.Value(ctxKey)
+		if value == nil {
+			return nil
+		}
+		return Fields{{Key: key, Value: value}}
+	}
+}
+
+// Return a context extractor which stamps the time left before the deadline
+// of context as field key, if the context has a deadline.
+func NewContextDeadlineExtractor(key string) ContextExtractor {
+	return func(ctx context.Context) Fields {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return nil
+		}
+		return Fields{{Key: key, Value: time.Until(deadline)}}
+	}
+}
+
+// Register the context extractor with the specified name.
+// An extractor already registered with the same name is replaced in place.
+// Extractors run in the order of registration.
+func (self *Manager) RegisterContextExtractor(
+	name string, extractor ContextExtractor) {
+
+	self.extractLock.Lock()
+	defer self.extractLock.Unlock()
+	for i, e := range self.extractors {
+		if e.name == name {
+			self.extractors[i].extractor = extractor
+			return
+		}
+	}
+	self.extractors = append(self.extractors, namedContextExtractor{
+		name:      name,
+		extractor: extractor,
+	})
+}
+
+// Remove the context extractor registered with the specified name.
+func (self *Manager) RemoveContextExtractor(name string) {
+	self.extractLock.Lock()
+	defer self.extractLock.Unlock()
+	for i, e := range self.extractors {
+		if e.name == name {
+			self.extractors = append(
+				self.extractors[:i], self.extractors[i+1:]...)
+			return
+		}
+	}
+}
+
+// Run all registered context extractors on the specified context and
+// return all the fields extracted.
+func (self *Manager) ExtractContext(ctx context.Context) Fields {
+	self.extractLock.RLock()
+	defer self.extractLock.RUnlock()
+	var fields Fields
+	for _, e := range self.extractors {
+		fields = append(fields, e.extractor(ctx)...)
+	}
+	return fields
+}
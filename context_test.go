@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+type testContextKey string
+
+func TestLoggerCtx(t *testing.T) {
+	defer Shutdown()
+	traceKey := testContextKey("trace")
+	RegisterContextExtractor("trace", NewContextValueExtractor("trace", traceKey))
+	RegisterContextExtractor("deadline", NewContextDeadlineExtractor("deadline"))
+	logger := GetLogger("ctx")
+	logger.SetLevel(LevelDebug)
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+
+	ctx := context.WithValue(context.Background(), traceKey, "t1")
+	logger.With("user", "abc").Ctx(ctx).Infof("msg: %d", 1)
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "msg: 1", record.GetMessage())
+	require.Equal(t, ctx, record.Context)
+	require.Equal(t, NewFields("user", "abc", "trace", "t1"), record.Fields)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	RemoveContextExtractor("trace")
+	logger.Ctx(ctx).Info("message")
+	record, err = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(record.Fields))
+	left, ok := record.Fields.Get("deadline")
+	require.True(t, ok)
+	require.True(t, left.(time.Duration) > 0)
+}
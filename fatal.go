@@ -0,0 +1,75 @@
+package logging
+
+// Type definition for what to do after a message is logged by
+// Logger.Fatal() and its siblings.
+type FatalPolicy uint8
+
+const (
+	// Just return to the caller, which is the default.
+	FatalContinue FatalPolicy = 0 + iota
+	// Exit the program with the exit code of manager.
+	FatalExit
+	// Panic with the fatal message.
+	FatalPanic
+)
+
+// Return the fatal policy of this manager.
+func (self *Manager) GetFatalPolicy() FatalPolicy {
+	self.fatalLock.RLock()
+	defer self.fatalLock.RUnlock()
+	return self.fatalPolicy
+}
+
+// Set the fatal policy of this manager.
+func (self *Manager) SetFatalPolicy(policy FatalPolicy) {
+	self.fatalLock.Lock()
+	defer self.fatalLock.Unlock()
+	self.fatalPolicy = policy
+}
+
+// Set the exit code used by FatalExit policy. It's 1 by default.
+func (self *Manager) SetExitCode(code int) {
+	self.fatalLock.Lock()
+	defer self.fatalLock.Unlock()
+	self.exitCode = code
+}
+
+// Add a hook to run before exiting or panicking on a fatal message.
+// Hooks run in the order they are added.
+func (self *Manager) AddExitHook(hook func()) {
+	self.fatalLock.Lock()
+	defer self.fatalLock.Unlock()
+	self.exitHooks = append(self.exitHooks, hook)
+}
+
+// Run all the exit hooks and then flush all handlers known to the closer
+// of this manager.
+func (self *Manager) RunExitHooks() {
+	self.fatalLock.RLock()
+	hooks := self.exitHooks
+	self.fatalLock.RUnlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	self.closer.Flush()
+}
+
+// Apply the fatal policy after the specified fatal message is logged.
+// For policy other than FatalContinue, the exit hooks are run and
+// all handlers are flushed before exiting or panicking, so that the fatal
+// message itself is never lost.
+func (self *Manager) HandleFatal(message string) {
+	self.fatalLock.RLock()
+	policy := self.fatalPolicy
+	code := self.exitCode
+	exitFunc := self.exitFunc
+	self.fatalLock.RUnlock()
+	switch policy {
+	case FatalExit:
+		self.RunExitHooks()
+		exitFunc(code)
+	case FatalPanic:
+		self.RunExitHooks()
+		panic(message)
+	}
+}
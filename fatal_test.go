@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestFatalPolicy_Panic(t *testing.T) {
+	defer Shutdown()
+	if FileExists(testFileName) {
+		require.Nil(t, os.Remove(testFileName))
+	}
+	handler, err := NewRotatingFileHandler(
+		testFileName,
+		os.O_APPEND,
+		1024,
+		time.Hour,
+		10,
+		0,
+		0)
+	require.Nil(t, err)
+	logger := GetLogger("fatal")
+	logger.AddHandler(handler)
+	hooked := false
+	AddExitHook(func() {
+		hooked = true
+	})
+	SetFatalPolicy(FatalPanic)
+	message := "fatal message"
+	func() {
+		defer func() {
+			require.Equal(t, message, recover())
+		}()
+		logger.Fatalf(message)
+	}()
+	require.True(t, hooked)
+	// the fatal message is flushed to file before panicking
+	content, err := ioutil.ReadFile(testFileName)
+	require.Nil(t, err)
+	require.Equal(t, message+"\n", string(content))
+	logger.RemoveHandler(handler)
+	handler.Close()
+	removeFile(t, testFileName)
+}
+
+func TestFatalPolicy_Exit(t *testing.T) {
+	defer Shutdown()
+	exitCode := -1
+	manager.exitFunc = func(code int) {
+		exitCode = code
+	}
+	logger := GetLogger("fatal")
+	logger.Fatal("message")
+	require.Equal(t, -1, exitCode)
+	SetFatalPolicy(FatalExit)
+	SetExitCode(2)
+	logger.With("a", 1).Fatal("message")
+	require.Equal(t, 2, exitCode)
+}
@@ -1,7 +1,10 @@
 package logging
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 )
 
 // Filter interface is to perform arbitrary filtering of LogRecords.
@@ -29,6 +32,11 @@ func NewNameFilter(name string) *NameFilter {
 	}
 }
 
+// Return the description of this filter.
+func (self *NameFilter) String() string {
+	return fmt.Sprintf("NameFilter(%q)", self.name)
+}
+
 // Determine if the specified record is to be logged.
 // Is the specified record to be logged? Returns false for no, true for yes.
 // If deemed appropriate, the record may be modified in-place.
@@ -44,6 +52,119 @@ func (self *NameFilter) Filter(record *LogRecord) bool {
 	return (record.Name[length] == '.')
 }
 
+// A filter which allows records of levels in the range [min, max].
+// If max is LevelNotset, there is no upper bound.
+type LevelRangeFilter struct {
+	min LogLevelType
+	max LogLevelType
+}
+
+// Initialize a level range filter.
+func NewLevelRangeFilter(min, max LogLevelType) *LevelRangeFilter {
+	return &LevelRangeFilter{
+		min: min,
+		max: max,
+	}
+}
+
+// Return the description of this filter.
+func (self *LevelRangeFilter) String() string {
+	return fmt.Sprintf("LevelRangeFilter(%s, %s)", self.min, self.max)
+}
+
+func (self *LevelRangeFilter) Filter(record *LogRecord) bool {
+	if record.Level < self.min {
+		return false
+	}
+	return (self.max == LevelNotset) || (record.Level <= self.max)
+}
+
+// A filter which allows records whose messages match the regular expression,
+// or the ones whose messages don't match it if exclude is true, e.g. to
+// exclude the messages of health checks.
+type MessageRegexFilter struct {
+	re      *regexp.Regexp
+	exclude bool
+}
+
+// Initialize a message regex filter.
+func NewMessageRegexFilter(
+	re *regexp.Regexp, exclude bool) *MessageRegexFilter {
+
+	return &MessageRegexFilter{
+		re:      re,
+		exclude: exclude,
+	}
+}
+
+// Return the description of this filter.
+func (self *MessageRegexFilter) String() string {
+	return fmt.Sprintf("MessageRegexFilter(%q, exclude=%t)",
+		self.re.String(), self.exclude)
+}
+
+func (self *MessageRegexFilter) Filter(record *LogRecord) bool {
+	return self.re.MatchString(record.GetMessage()) != self.exclude
+}
+
+// A filter which allows records whose logger names match the regular
+// expression, or the ones whose logger names don't match it if exclude
+// is true.
+type NameRegexFilter struct {
+	re      *regexp.Regexp
+	exclude bool
+}
+
+// Initialize a name regex filter.
+func NewNameRegexFilter(re *regexp.Regexp, exclude bool) *NameRegexFilter {
+	return &NameRegexFilter{
+		re:      re,
+		exclude: exclude,
+	}
+}
+
+// Return the description of this filter.
+func (self *NameRegexFilter) String() string {
+	return fmt.Sprintf("NameRegexFilter(%q, exclude=%t)",
+		self.re.String(), self.exclude)
+}
+
+func (self *NameRegexFilter) Filter(record *LogRecord) bool {
+	return self.re.MatchString(record.Name) != self.exclude
+}
+
+// A filter which allows records with a field of the specified key and value,
+// or the ones without such a field if exclude is true. The values are
+// compared in their default formats by fmt.Sprint(), so that a value from
+// config file, e.g. "200", is equal to a field of any type printed the same,
+// e.g. int 200.
+type FieldFilter struct {
+	key     string
+	value   string
+	exclude bool
+}
+
+// Initialize a field filter.
+func NewFieldFilter(key string, value interface{}, exclude bool) *FieldFilter {
+	return &FieldFilter{
+		key:     key,
+		value:   fmt.Sprint(value),
+		exclude: exclude,
+	}
+}
+
+// Return the description of this filter.
+func (self *FieldFilter) String() string {
+	return fmt.Sprintf("FieldFilter(%s=%s, exclude=%t)",
+		self.key, self.value, self.exclude)
+}
+
+func (self *FieldFilter) Filter(record *LogRecord) bool {
+	value, ok := record.Fields.Get(self.key)
+	matched := ok && (fmt.Sprint(value) == self.value)
+	return matched != self.exclude
+}
+
 // An interface for managing filters.
 type Filterer interface {
 	AddFilter(filter Filter)
@@ -55,6 +176,7 @@ type Filterer interface {
 // of managing the filters.
 type StandardFilterer struct {
 	filters *ListSet
+	lock    sync.RWMutex
 }
 
 // Initialize the standard filterer, with no filter.
@@ -65,16 +187,26 @@ func NewStandardFilterer() *StandardFilterer {
 }
 
 // Add the specified filter.
+// The filters are copied on write, so that it's safe to add or remove
+// filters while records are being filtered, e.g. by ReloadConfig().
 func (self *StandardFilterer) AddFilter(filter Filter) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
 	if !self.filters.SetContains(filter) {
-		self.filters.SetAdd(filter)
+		filters := self.filters.SetClone()
+		filters.SetAdd(filter)
+		self.filters = filters
 	}
 }
 
 // Remove the specified filter.
 func (self *StandardFilterer) RemoveFilter(filter Filter) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
 	if self.filters.SetContains(filter) {
-		self.filters.SetRemove(filter)
+		filters := self.filters.SetClone()
+		filters.SetRemove(filter)
+		self.filters = filters
 	}
 }
 
@@ -84,7 +216,7 @@ func (self *StandardFilterer) RemoveFilter(filter Filter) {
 // is to be dropped, else non-zero.
 func (self *StandardFilterer) Filter(record *LogRecord) int {
 	recordVote := 1
-	for e := self.filters.Front(); e != nil; e = e.Next() {
+	for e := self.GetFilters().Front(); e != nil; e = e.Next() {
 		filter, _ := e.Value.(Filter)
 		if !filter.Filter(record) {
 			recordVote = 0
@@ -94,7 +226,10 @@ func (self *StandardFilterer) Filter(record *LogRecord) int {
 	return recordVote
 }
 
-// Return all the filter in this filterer.
+// Return all the filter in this filterer. The result should be taken as
+// read-only, since it's shared until any filter is added or removed.
 func (self *StandardFilterer) GetFilters() *ListSet {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
 	return self.filters
 }
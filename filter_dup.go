@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A filter which suppresses the repeats of the previous record, as syslog
+// daemons do. A record is a repeat if it has the same level and message as
+// the previous record which passed, and arrives within the window since then.
+//
+// The repeats are counted, and a record with the message "last message
+// repeated N times" is emitted when a different record arrives, before it
+// passes, or when the window elapses, whichever comes first, if it's attached
+// to a logger or handler by AttachFilter(). After the window elapses, the
+// repeats since then are suppressed and counted in a new window.
+type DuplicateFilter struct {
+	*recordEmitter
+	window     time.Duration
+	last       *LogRecord
+	message    string
+	start      time.Time
+	repeated   uint64
+	suppressed uint64
+	timer      *time.Timer
+	lock       sync.Mutex
+}
+
+// Initialize a duplicate filter with the window of repeats.
+func NewDuplicateFilter(window time.Duration) *DuplicateFilter {
+	return &DuplicateFilter{
+		recordEmitter: newRecordEmitter(),
+		window:        window,
+	}
+}
+
+// Return the description of this filter.
+func (self *DuplicateFilter) String() string {
+	return fmt.Sprintf("DuplicateFilter(window=%s)", self.window)
+}
+
+// Return the total number of records suppressed.
+func (self *DuplicateFilter) GetSuppressed() uint64 {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.suppressed
+}
+
+func (self *DuplicateFilter) Filter(record *LogRecord) bool {
+	if self.isEmitting(record) {
+		return true
+	}
+	message := record.GetMessage()
+	now := time.Now()
+	self.lock.Lock()
+	if (self.last != nil) &&
+		(record.Level == self.last.Level) &&
+		(message == self.message) &&
+		(now.Sub(self.start) < self.window) {
+
+		self.repeated++
+		self.suppressed++
+		if self.timer == nil {
+			self.timer = time.AfterFunc(
+				self.window-now.Sub(self.start), self.flushWindow)
+		}
+		self.lock.Unlock()
+		return false
+	}
+	summary := self.takeSummary()
+	self.last = record
+	self.message = message
+	self.start = now
+	self.lock.Unlock()
+	if summary != nil {
+		self.emitRecords(summary)
+	}
+	return true
+}
+
+// Emit the summary of the repeats in the window which elapses, and start
+// a new window for the following repeats.
+func (self *DuplicateFilter) flushWindow() {
+	self.lock.Lock()
+	summary := self.takeSummary()
+	self.start = time.Now()
+	self.lock.Unlock()
+	if summary != nil {
+		self.emitRecords(summary)
+	}
+}
+
+// Emit the summary of the repeats so far right now.
+func (self *DuplicateFilter) Flush() {
+	self.lock.Lock()
+	summary := self.takeSummary()
+	self.lock.Unlock()
+	if summary != nil {
+		self.emitRecords(summary)
+	}
+}
+
+// Return the summary record of the repeats and reset the repeat counter,
+// or nil if there is no repeat. The lock should be held.
+func (self *DuplicateFilter) takeSummary() *LogRecord {
+	if self.timer != nil {
+		self.timer.Stop()
+		self.timer = nil
+	}
+	if self.repeated == 0 {
+		return nil
+	}
+	record := newSummaryRecord(
+		self.last, "last message repeated %d times", self.repeated)
+	record.Fields = NewFields("repeated", self.repeated)
+	self.repeated = 0
+	return record
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestDuplicateFilter(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelDebug))
+	defer manager.Shutdown()
+	logger := manager.GetLogger("a")
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+	filter := NewDuplicateFilter(time.Millisecond * 100)
+	AttachFilter(logger, filter)
+	expect := func(level LogLevelType, message string) {
+		record, err := handler.GetEmitOnTimeout(time.Second)
+		require.Nil(t, err)
+		require.Equal(t, level, record.Level)
+		require.Equal(t, message, record.GetMessage())
+	}
+
+	// repeats are summarized when a different record arrives
+	for i := 0; i < 4; i++ {
+		logger.Info("message")
+	}
+	logger.Warn("message")
+	expect(LevelInfo, "message")
+	expect(LevelInfo, "last message repeated 3 times")
+	expect(LevelWarn, "message")
+	require.Equal(t, uint64(3), filter.GetSuppressed())
+
+	// repeats are summarized when the window elapses
+	logger.Warn("message")
+	logger.Warn("message")
+	expect(LevelWarn, "last message repeated 2 times")
+	_, err := handler.GetEmitOnTimeout(time.Millisecond * 10)
+	require.Equal(t, ErrorTimeout, err)
+
+	// no summary without repeats
+	time.Sleep(time.Millisecond * 150)
+	logger.Warn("message")
+	logger.Warn("another")
+	expect(LevelWarn, "message")
+	expect(LevelWarn, "another")
+	filter.Flush()
+	_, err = handler.GetEmitOnTimeout(time.Millisecond * 150)
+	require.Equal(t, ErrorTimeout, err)
+	require.Equal(t, uint64(5), filter.GetSuppressed())
+}
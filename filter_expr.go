@@ -0,0 +1,589 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A filter which allows records matching a boolean expression over their
+// attributes, for example
+//     level >= WARN && name =~ "^db\." && !(message contains "ping")
+//
+// An expression is made of comparisons combined by "&&", "||", "!" and
+// parentheses, along with the constants true and false. A comparison is
+// made of two operands and one of the operators:
+//     ==  !=  <  <=  >  >=    equality and order
+//     =~  !~                  match of regular expression, or not
+//     contains  startsWith  endsWith
+//
+// An operand is a string in double quotes or backquotes as in Go, a number,
+// or one of the attributes of a record:
+//     name      the logger name
+//     level     the level, which is compared with a level name like WARN or
+//               "warn", or a level number
+//     file      the file name of the caller
+//     line      the line number of the caller
+//     function  the function name of the caller
+//     message   the message
+//     fields.key or fields["key"]
+//               the value of the field with the key
+//
+// Numbers are compared by value, and all others by their default formats.
+// A comparison with a field which a record doesn't have is false, except
+// that "!=" is true. The right operand of "=~" and "!~" should be a string.
+type ExprFilter struct {
+	expr string
+	eval func(record *LogRecord) bool
+}
+
+// Initialize an expression filter, or return the error in the expression.
+func NewExprFilter(expr string) (*ExprFilter, error) {
+	tokens, err := lexFilterExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	parser := &exprParser{
+		expr:   expr,
+		tokens: tokens,
+	}
+	eval, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &ExprFilter{
+		expr: expr,
+		eval: eval,
+	}, nil
+}
+
+// Return the description of this filter.
+func (self *ExprFilter) String() string {
+	return fmt.Sprintf("ExprFilter(%q)", self.expr)
+}
+
+func (self *ExprFilter) Filter(record *LogRecord) bool {
+	return self.eval(record)
+}
+
+type exprTokenKind uint8
+
+const (
+	exprTokenEOF exprTokenKind = iota
+	exprTokenIdent
+	exprTokenString
+	exprTokenNumber
+	exprTokenPunct
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+	pos  int
+	str  string
+	num  float64
+}
+
+// The punctuations, with longer ones before their prefixes.
+var exprPuncts = []string{
+	"&&", "||", "==", "!=", "<=", ">=", "=~", "!~",
+	"<", ">", "!", "(", ")", "[", "]",
+}
+
+func exprError(expr string, pos int, format string, args ...interface{}) error {
+	return errors.New(fmt.Sprintf("%s at column %d of filter expression: %s",
+		fmt.Sprintf(format, args...), pos+1, expr))
+}
+
+func isExprIdentStart(c byte) bool {
+	return (c == '_') || ((c >= 'a') && (c <= 'z')) || ((c >= 'A') && (c <= 'Z'))
+}
+
+func isExprDigit(c byte) bool {
+	return (c >= '0') && (c <= '9')
+}
+
+// Split the expression into tokens, ended by an EOF token.
+func lexFilterExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case (c == ' ') || (c == '\t') || (c == '\n') || (c == '\r'):
+			i++
+		case isExprIdentStart(c):
+			start := i
+			for (i < len(expr)) && (isExprIdentStart(expr[i]) ||
+				isExprDigit(expr[i]) || (expr[i] == '.')) {
+				i++
+			}
+			tokens = append(tokens, exprToken{
+				kind: exprTokenIdent,
+				text: expr[start:i],
+				pos:  start,
+			})
+		case isExprDigit(c) ||
+			((c == '-') && (i+1 < len(expr)) && isExprDigit(expr[i+1])):
+			start := i
+			i++
+			for (i < len(expr)) && (isExprDigit(expr[i]) || (expr[i] == '.')) {
+				i++
+			}
+			num, err := strconv.ParseFloat(expr[start:i], 64)
+			if err != nil {
+				return nil, exprError(
+					expr, start, "invalid number %q", expr[start:i])
+			}
+			tokens = append(tokens, exprToken{
+				kind: exprTokenNumber,
+				text: expr[start:i],
+				pos:  start,
+				num:  num,
+			})
+		case (c == '"') || (c == '`'):
+			start := i
+			i++
+			for (i < len(expr)) && (expr[i] != c) {
+				if (c == '"') && (expr[i] == '\\') {
+					i++
+				}
+				i++
+			}
+			if i >= len(expr) {
+				return nil, exprError(expr, start, "unterminated string")
+			}
+			i++
+			str, err := strconv.Unquote(expr[start:i])
+			if err != nil {
+				return nil, exprError(
+					expr, start, "invalid string %s", expr[start:i])
+			}
+			tokens = append(tokens, exprToken{
+				kind: exprTokenString,
+				text: expr[start:i],
+				pos:  start,
+				str:  str,
+			})
+		default:
+			found := false
+			for _, punct := range exprPuncts {
+				if strings.HasPrefix(expr[i:], punct) {
+					tokens = append(tokens, exprToken{
+						kind: exprTokenPunct,
+						text: punct,
+						pos:  i,
+					})
+					i += len(punct)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, exprError(
+					expr, i, "unexpected character %q", expr[i])
+			}
+		}
+	}
+	tokens = append(tokens, exprToken{
+		kind: exprTokenEOF,
+		pos:  len(expr),
+	})
+	return tokens, nil
+}
+
+type exprValueKind uint8
+
+const (
+	exprValueMissing exprValueKind = iota
+	exprValueString
+	exprValueNumber
+)
+
+// The value of an operand on a record.
+type exprValue struct {
+	kind exprValueKind
+	str  string
+	num  float64
+}
+
+func (self exprValue) String() string {
+	if self.kind == exprValueNumber {
+		return strconv.FormatFloat(self.num, 'g', -1, 64)
+	}
+	return self.str
+}
+
+// Return the number of this value, which is parsed from a string if
+// possible.
+func (self exprValue) number() (float64, bool) {
+	switch self.kind {
+	case exprValueNumber:
+		return self.num, true
+	case exprValueString:
+		num, err := strconv.ParseFloat(self.str, 64)
+		return num, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Return the value of a field.
+func newExprFieldValue(value interface{}) exprValue {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return exprValue{kind: exprValueNumber, num: float64(v.Int())}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return exprValue{kind: exprValueNumber, num: float64(v.Uint())}
+	case reflect.Float32, reflect.Float64:
+		return exprValue{kind: exprValueNumber, num: v.Float()}
+	default:
+		return exprValue{kind: exprValueString, str: fmt.Sprint(value)}
+	}
+}
+
+// An operand in a comparison.
+type exprOperand struct {
+	token exprToken
+	// the attribute name, or empty for a literal or bare word
+	attr string
+	get  func(record *LogRecord) exprValue
+}
+
+var exprAttrs = map[string]func(record *LogRecord) exprValue{
+	"name": func(record *LogRecord) exprValue {
+		return exprValue{kind: exprValueString, str: record.Name}
+	},
+	"level": func(record *LogRecord) exprValue {
+		return exprValue{kind: exprValueNumber, num: float64(record.Level)}
+	},
+	"file": func(record *LogRecord) exprValue {
+		return exprValue{kind: exprValueString, str: record.FileName}
+	},
+	"line": func(record *LogRecord) exprValue {
+		return exprValue{kind: exprValueNumber, num: float64(record.LineNo)}
+	},
+	"function": func(record *LogRecord) exprValue {
+		return exprValue{kind: exprValueString, str: record.FuncName}
+	},
+	"message": func(record *LogRecord) exprValue {
+		return exprValue{kind: exprValueString, str: record.GetMessage()}
+	},
+}
+
+var exprCompareOps = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"=~": true, "!~": true,
+	"contains": true, "startsWith": true, "endsWith": true,
+}
+
+type exprParser struct {
+	expr   string
+	tokens []exprToken
+	pos    int
+}
+
+func (self *exprParser) peek() exprToken {
+	return self.tokens[self.pos]
+}
+
+func (self *exprParser) next() exprToken {
+	token := self.tokens[self.pos]
+	if token.kind != exprTokenEOF {
+		self.pos++
+	}
+	return token
+}
+
+func (self *exprParser) isPunct(text string) bool {
+	token := self.peek()
+	return (token.kind == exprTokenPunct) && (token.text == text)
+}
+
+func (self *exprParser) errorf(
+	token exprToken, format string, args ...interface{}) error {
+
+	if token.kind == exprTokenEOF {
+		return exprError(self.expr, token.pos,
+			"unexpected end, "+format, args...)
+	}
+	return exprError(self.expr, token.pos, format, args...)
+}
+
+func (self *exprParser) parse() (func(record *LogRecord) bool, error) {
+	eval, err := self.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := self.peek(); token.kind != exprTokenEOF {
+		return nil, self.errorf(token, "unexpected %s", token.text)
+	}
+	return eval, nil
+}
+
+func (self *exprParser) parseOr() (func(record *LogRecord) bool, error) {
+	left, err := self.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for self.isPunct("||") {
+		self.next()
+		right, err := self.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(record *LogRecord) bool {
+			return l(record) || r(record)
+		}
+	}
+	return left, nil
+}
+
+func (self *exprParser) parseAnd() (func(record *LogRecord) bool, error) {
+	left, err := self.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for self.isPunct("&&") {
+		self.next()
+		right, err := self.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(record *LogRecord) bool {
+			return l(record) && r(record)
+		}
+	}
+	return left, nil
+}
+
+func (self *exprParser) parseUnary() (func(record *LogRecord) bool, error) {
+	if self.isPunct("!") {
+		self.next()
+		inner, err := self.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(record *LogRecord) bool {
+			return !inner(record)
+		}, nil
+	}
+	return self.parsePrimary()
+}
+
+func (self *exprParser) parsePrimary() (func(record *LogRecord) bool, error) {
+	if self.isPunct("(") {
+		self.next()
+		inner, err := self.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !self.isPunct(")") {
+			return nil, self.errorf(self.peek(), "expect )")
+		}
+		self.next()
+		return inner, nil
+	}
+	if token := self.peek(); token.kind == exprTokenIdent {
+		switch token.text {
+		case "true", "false":
+			self.next()
+			result := (token.text == "true")
+			return func(record *LogRecord) bool {
+				return result
+			}, nil
+		}
+	}
+	return self.parseComparison()
+}
+
+func (self *exprParser) parseOperand() (*exprOperand, error) {
+	token := self.next()
+	operand := &exprOperand{
+		token: token,
+	}
+	switch token.kind {
+	case exprTokenString:
+		value := exprValue{kind: exprValueString, str: token.str}
+		operand.get = func(record *LogRecord) exprValue {
+			return value
+		}
+	case exprTokenNumber:
+		value := exprValue{kind: exprValueNumber, num: token.num}
+		operand.get = func(record *LogRecord) exprValue {
+			return value
+		}
+	case exprTokenIdent:
+		var key string
+		if strings.HasPrefix(token.text, "fields.") {
+			key = token.text[len("fields."):]
+			if len(key) == 0 {
+				return nil, self.errorf(token, "expect field key")
+			}
+		} else if token.text == "fields" {
+			if !self.isPunct("[") {
+				return nil, self.errorf(self.peek(), "expect [ after fields")
+			}
+			self.next()
+			keyToken := self.next()
+			if keyToken.kind != exprTokenString {
+				return nil, self.errorf(keyToken, "expect field key string")
+			}
+			if !self.isPunct("]") {
+				return nil, self.errorf(self.peek(), "expect ]")
+			}
+			self.next()
+			key = keyToken.str
+		} else {
+			if get, ok := exprAttrs[token.text]; ok {
+				operand.attr = token.text
+				operand.get = get
+			}
+			return operand, nil
+		}
+		operand.attr = "fields"
+		operand.get = func(record *LogRecord) exprValue {
+			value, ok := record.Fields.Get(key)
+			if !ok {
+				return exprValue{}
+			}
+			return newExprFieldValue(value)
+		}
+	default:
+		return nil, self.errorf(token, "expect operand")
+	}
+	return operand, nil
+}
+
+// Resolve the operand compared with level to a level number, if it's a
+// level name in a string or bare word.
+func (self *exprParser) resolveLevel(operand *exprOperand) error {
+	token := operand.token
+	if len(operand.attr) > 0 {
+		return nil
+	}
+	if (token.kind != exprTokenIdent) && (token.kind != exprTokenString) {
+		return nil
+	}
+	name := token.text
+	if token.kind == exprTokenString {
+		name = token.str
+	}
+	level, ok := GetNameLevel(strings.ToUpper(name))
+	if !ok {
+		return self.errorf(token, "unknown level %s", name)
+	}
+	value := exprValue{kind: exprValueNumber, num: float64(level)}
+	operand.get = func(record *LogRecord) exprValue {
+		return value
+	}
+	return nil
+}
+
+func (self *exprParser) parseComparison() (
+	func(record *LogRecord) bool, error) {
+
+	left, err := self.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	opToken := self.next()
+	op := opToken.text
+	if ((opToken.kind != exprTokenPunct) && (opToken.kind != exprTokenIdent)) ||
+		!exprCompareOps[op] {
+		return nil, self.errorf(opToken, "expect comparison operator")
+	}
+	right, err := self.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if (left.attr == "level") && (right.attr != "level") {
+		err = self.resolveLevel(right)
+	} else if (right.attr == "level") && (left.attr != "level") {
+		err = self.resolveLevel(left)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, operand := range []*exprOperand{left, right} {
+		if (operand.get == nil) && (operand.token.kind == exprTokenIdent) {
+			return nil, self.errorf(
+				operand.token, "unknown attribute %s", operand.token.text)
+		}
+	}
+	getLeft, getRight := left.get, right.get
+	switch op {
+	case "=~", "!~":
+		if right.token.kind != exprTokenString {
+			return nil, self.errorf(
+				right.token, "expect regular expression string")
+		}
+		re, err := regexp.Compile(right.token.str)
+		if err != nil {
+			return nil, self.errorf(right.token, "%s", err.Error())
+		}
+		matched := (op == "=~")
+		return func(record *LogRecord) bool {
+			value := getLeft(record)
+			if value.kind == exprValueMissing {
+				return false
+			}
+			return re.MatchString(value.String()) == matched
+		}, nil
+	default:
+		return func(record *LogRecord) bool {
+			return compareExprValues(op, getLeft(record), getRight(record))
+		}, nil
+	}
+}
+
+// Compare the two values by the operator.
+func compareExprValues(op string, a, b exprValue) bool {
+	if (a.kind == exprValueMissing) || (b.kind == exprValueMissing) {
+		return op == "!="
+	}
+	switch op {
+	case "contains":
+		return strings.Contains(a.String(), b.String())
+	case "startsWith":
+		return strings.HasPrefix(a.String(), b.String())
+	case "endsWith":
+		return strings.HasSuffix(a.String(), b.String())
+	}
+	var result int
+	numA, okA := a.number()
+	numB, okB := b.number()
+	if ((a.kind == exprValueNumber) || (b.kind == exprValueNumber)) &&
+		okA && okB {
+		switch {
+		case numA < numB:
+			result = -1
+		case numA > numB:
+			result = 1
+		}
+	} else {
+		result = strings.Compare(a.String(), b.String())
+	}
+	switch op {
+	case "==":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	default:
+		return result >= 0
+	}
+}
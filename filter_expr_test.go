@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestExprFilter(t *testing.T) {
+	record := NewLogRecord("db.pool", LevelWarn, "/src/pool.go", "pool.go",
+		42, "pool.Get", "%s", true, []interface{}{"connection refused"})
+	record.Fields = NewFields("status", 503, "user", "bob", "ratio", 0.5)
+	cases := []struct {
+		expr   string
+		result bool
+	}{
+		{`true`, true},
+		{`false || !true`, false},
+		{`level >= WARN`, true},
+		{`level > warn`, false},
+		{`level == "WARN"`, true},
+		{`level < 40`, true},
+		{`name =~ "^db\\."`, true},
+		{"name !~ `^db\\.`", false},
+		{`name == "db.pool" && line == 42`, true},
+		{`file == "pool.go" && function endsWith ".Get"`, true},
+		{`message contains "refused"`, true},
+		{`message startsWith "refused"`, false},
+		{`!(message contains "ping")`, true},
+		{`fields.status >= 500 && fields.status < 600`, true},
+		{`fields.status == "503"`, true},
+		{`fields["user"] == "bob"`, true},
+		{`fields.ratio < 1`, true},
+		{`fields.missing == 1`, false},
+		{`fields.missing != 1`, true},
+		{`fields.missing =~ ".*"`, false},
+		{`level >= ERROR || (name == "db.pool" && !(level < WARN))`, true},
+		{`level >= ERROR || name == "x" && level >= WARN`, false},
+	}
+	for _, c := range cases {
+		filter, err := NewExprFilter(c.expr)
+		require.Nil(t, err, c.expr)
+		require.Equal(t, c.result, filter.Filter(record), c.expr)
+	}
+}
+
+func TestExprFilter_Errors(t *testing.T) {
+	cases := []struct {
+		expr  string
+		error string
+	}{
+		{``, "unexpected end, expect operand at column 1"},
+		{`level >= `, "unexpected end, expect operand at column 10"},
+		{`level >= LOUD`, "unknown level LOUD at column 10"},
+		{`size > 1`, "unknown attribute size at column 1"},
+		{`name is "a"`, "expect comparison operator at column 6"},
+		{`(name == "a"`, "unexpected end, expect ) at column 13"},
+		{`name == "a" name`, "unexpected name at column 13"},
+		{`name == "a`, "unterminated string at column 9"},
+		{`name =~ "("`, "missing closing ): `(` at column 9"},
+		{`name =~ name`, "expect regular expression string at column 9"},
+		{`name == 'a'`, "unexpected character '\\'' at column 9"},
+		{`fields[1] == 1`, "expect field key string at column 8"},
+	}
+	for _, c := range cases {
+		_, err := NewExprFilter(c.expr)
+		require.NotNil(t, err, c.expr)
+		require.True(t, strings.Contains(err.Error(), c.error), err.Error())
+		require.True(t, strings.HasSuffix(err.Error(), ": "+c.expr), err.Error())
+	}
+}
+
+func TestConfigExprFilter(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Filters: map[string]ConfFilter{
+			"f": {Type: "expr", Expr: `level >= WARN && name =~ "^db\\."`},
+		},
+		Loggers: map[string]ConfMap{
+			"a": {"filters": []interface{}{"f"}},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	filters := manager.GetLogger("a").(*StandardLogger).GetFilters()
+	require.Equal(t, 1, filters.Len())
+	_, ok := filters.Front().Value.(*ExprFilter)
+	require.True(t, ok)
+	conf.Filters["f"] = ConfFilter{Type: "expr", Expr: "level >="}
+	require.NotNil(t, manager.DictConfig(conf))
+}
@@ -0,0 +1,373 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Filters which emit records of their own, e.g. the summary of suppressed
+// records, to the logger or handler which they are attached to.
+type EmittingFilter interface {
+	Filter
+	// Set the function to emit the records of this filter.
+	SetEmitter(emit func(record *LogRecord))
+}
+
+// Add the filter to the specified logger or handler. If the filter is
+// an EmittingFilter, its records are emitted to the logger or handler.
+// Since there is only one emitter for a filter, an EmittingFilter should be
+// attached to only one logger or handler.
+func AttachFilter(filterer Filterer, filter Filter) {
+	filterer.AddFilter(filter)
+	emitting, ok := filter.(EmittingFilter)
+	if !ok {
+		return
+	}
+	switch target := filterer.(type) {
+	case Logger:
+		emitting.SetEmitter(target.Handle)
+	case Handler:
+		emitting.SetEmitter(func(record *LogRecord) {
+			target.Handle(record)
+		})
+	}
+}
+
+// The key of a record for rate limiting and sampling, which is made up of
+// its logger, level and caller file:line.
+type recordKey struct {
+	name  string
+	level LogLevelType
+	path  string
+	line  uint32
+}
+
+func newRecordKey(record *LogRecord) recordKey {
+	return recordKey{
+		name:  record.Name,
+		level: record.Level,
+		path:  record.PathName,
+		line:  record.LineNo,
+	}
+}
+
+// The records suppressed for a key since the last summary.
+type suppressedRecords struct {
+	count uint64
+	last  *LogRecord
+}
+
+// The emitter of the records generated by a filter, which remembers the
+// records being emitted so that they could pass the filter itself.
+type recordEmitter struct {
+	emit     func(record *LogRecord)
+	emitting map[*LogRecord]bool
+	emitLock sync.Mutex
+}
+
+func newRecordEmitter() *recordEmitter {
+	return &recordEmitter{
+		emitting: make(map[*LogRecord]bool),
+	}
+}
+
+func (self *recordEmitter) SetEmitter(emit func(record *LogRecord)) {
+	self.emitLock.Lock()
+	defer self.emitLock.Unlock()
+	self.emit = emit
+}
+
+// Report whether the record is being emitted by this emitter.
+func (self *recordEmitter) isEmitting(record *LogRecord) bool {
+	self.emitLock.Lock()
+	defer self.emitLock.Unlock()
+	return self.emitting[record]
+}
+
+// Emit the records. They are dropped if there is no emitter set.
+// No lock of the filter should be held, since the records would go through
+// the filter again.
+func (self *recordEmitter) emitRecords(records ...*LogRecord) {
+	self.emitLock.Lock()
+	emit := self.emit
+	if emit == nil {
+		self.emitLock.Unlock()
+		return
+	}
+	for _, record := range records {
+		self.emitting[record] = true
+	}
+	self.emitLock.Unlock()
+	for _, record := range records {
+		emit(record)
+	}
+	self.emitLock.Lock()
+	for _, record := range records {
+		delete(self.emitting, record)
+	}
+	self.emitLock.Unlock()
+}
+
+// The counters of suppressed records, which are reported in summary records
+// periodically. It's shared by the filters which suppress records.
+type suppressCounter struct {
+	*recordEmitter
+	kind     string
+	interval time.Duration
+	total    uint64
+	counts   map[recordKey]*suppressedRecords
+	timer    *time.Timer
+	lock     sync.Mutex
+}
+
+func newSuppressCounter(
+	kind string, interval time.Duration) *suppressCounter {
+
+	return &suppressCounter{
+		recordEmitter: newRecordEmitter(),
+		kind:          kind,
+		interval:      interval,
+		counts:        make(map[recordKey]*suppressedRecords),
+	}
+}
+
+// Return the total number of records suppressed.
+func (self *suppressCounter) GetSuppressed() uint64 {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.total
+}
+
+// Count the suppressed record, and schedule the summary if it's the first
+// one since the last summary. The lock should be held.
+func (self *suppressCounter) suppress(key recordKey, record *LogRecord) {
+	self.total++
+	if self.interval <= 0 {
+		return
+	}
+	suppressed, ok := self.counts[key]
+	if !ok {
+		suppressed = &suppressedRecords{}
+		self.counts[key] = suppressed
+	}
+	suppressed.count++
+	suppressed.last = record
+	if self.timer == nil {
+		self.timer = time.AfterFunc(self.interval, self.Summarize)
+	}
+}
+
+// Emit the summary records of the records suppressed since the last summary,
+// one for every key, right now.
+func (self *suppressCounter) Summarize() {
+	self.lock.Lock()
+	if self.timer != nil {
+		self.timer.Stop()
+		self.timer = nil
+	}
+	counts := self.counts
+	self.counts = make(map[recordKey]*suppressedRecords)
+	self.lock.Unlock()
+	records := make([]*LogRecord, 0, len(counts))
+	for _, suppressed := range counts {
+		record := newSummaryRecord(
+			suppressed.last,
+			"%d records suppressed by %s in last %s, the last one: %s",
+			suppressed.count,
+			self.kind,
+			self.interval,
+			suppressed.last.GetMessage())
+		record.Fields = NewFields("suppressed", suppressed.count)
+		records = append(records, record)
+	}
+	self.emitRecords(records...)
+}
+
+// Return a new record of the same logger, level and caller as the specified
+// record, with the message formatted from format and args.
+func newSummaryRecord(
+	last *LogRecord, format string, args ...interface{}) *LogRecord {
+
+	return NewLogRecord(
+		last.Name,
+		last.Level,
+		last.PathName,
+		last.FileName,
+		last.LineNo,
+		last.FuncName,
+		format,
+		true,
+		args)
+}
+
+// A token bucket.
+type tokenBucket struct {
+	tokens float64
+	time   time.Time
+}
+
+// A filter which limits the rate of records by token buckets, one for every
+// logger, level and caller file:line. Every bucket holds at most burst
+// tokens, which are refilled at the rate of tokens per second. A record
+// passes if there is a token in its bucket, and is suppressed otherwise.
+//
+// The records suppressed are counted, and a summary record for every key
+// is emitted at the end of the summary interval in which any record is
+// suppressed, when it's attached to a logger or handler by AttachFilter().
+type RateLimitFilter struct {
+	*suppressCounter
+	rate    float64
+	burst   float64
+	buckets map[recordKey]*tokenBucket
+}
+
+// Initialize a rate limit filter with rate of records per second and
+// the burst of records. The summary is disabled if summaryInterval isn't
+// positive.
+func NewRateLimitFilter(
+	rate float64, burst int, summaryInterval time.Duration) *RateLimitFilter {
+
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimitFilter{
+		suppressCounter: newSuppressCounter("rate limit", summaryInterval),
+		rate:            rate,
+		burst:           float64(burst),
+		buckets:         make(map[recordKey]*tokenBucket),
+	}
+}
+
+// Return the description of this filter.
+func (self *RateLimitFilter) String() string {
+	return fmt.Sprintf("RateLimitFilter(rate=%g, burst=%g)", self.rate, self.burst)
+}
+
+func (self *RateLimitFilter) Filter(record *LogRecord) bool {
+	if self.isEmitting(record) {
+		return true
+	}
+	key := newRecordKey(record)
+	now := time.Now()
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	bucket, ok := self.buckets[key]
+	if !ok {
+		if len(self.buckets) >= maxRecordKeys {
+			self.prune(now)
+		}
+		bucket = &tokenBucket{
+			tokens: self.burst,
+			time:   now,
+		}
+		self.buckets[key] = bucket
+	} else {
+		bucket.tokens += now.Sub(bucket.time).Seconds() * self.rate
+		if bucket.tokens > self.burst {
+			bucket.tokens = self.burst
+		}
+		bucket.time = now
+	}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true
+	}
+	self.suppress(key, record)
+	return false
+}
+
+// The number of keys above which the idle ones are pruned.
+const maxRecordKeys = 10000
+
+// Remove the buckets which are full by now, which are the same as new ones.
+func (self *RateLimitFilter) prune(now time.Time) {
+	for key, bucket := range self.buckets {
+		tokens := bucket.tokens + now.Sub(bucket.time).Seconds()*self.rate
+		if tokens >= self.burst {
+			delete(self.buckets, key)
+		}
+	}
+}
+
+// The sampling state of a key in the current interval.
+type sampleCounter struct {
+	count uint64
+	start time.Time
+}
+
+// A filter which samples records, one key for every logger, level and
+// caller file:line. In every interval, the first records of a key pass,
+// then only one of every thereafter records passes. If thereafter isn't
+// positive, no record passes after the first ones.
+//
+// The records suppressed are counted and summarized as RateLimitFilter does.
+type SamplingFilter struct {
+	*suppressCounter
+	first      uint64
+	thereafter uint64
+	interval   time.Duration
+	counters   map[recordKey]*sampleCounter
+}
+
+// Initialize a sampling filter. The summary is disabled if summaryInterval
+// isn't positive.
+func NewSamplingFilter(
+	first int,
+	thereafter int,
+	interval time.Duration,
+	summaryInterval time.Duration) *SamplingFilter {
+
+	object := &SamplingFilter{
+		suppressCounter: newSuppressCounter("sampling", summaryInterval),
+		interval:        interval,
+		counters:        make(map[recordKey]*sampleCounter),
+	}
+	if first > 0 {
+		object.first = uint64(first)
+	}
+	if thereafter > 0 {
+		object.thereafter = uint64(thereafter)
+	}
+	return object
+}
+
+// Return the description of this filter.
+func (self *SamplingFilter) String() string {
+	return fmt.Sprintf("SamplingFilter(first=%d, thereafter=%d, interval=%s)",
+		self.first, self.thereafter, self.interval)
+}
+
+func (self *SamplingFilter) Filter(record *LogRecord) bool {
+	if self.isEmitting(record) {
+		return true
+	}
+	key := newRecordKey(record)
+	now := time.Now()
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	counter, ok := self.counters[key]
+	if !ok || (now.Sub(counter.start) >= self.interval) {
+		if !ok && (len(self.counters) >= maxRecordKeys) {
+			for k, c := range self.counters {
+				if now.Sub(c.start) >= self.interval {
+					delete(self.counters, k)
+				}
+			}
+		}
+		counter = &sampleCounter{
+			start: now,
+		}
+		self.counters[key] = counter
+	}
+	counter.count++
+	if counter.count <= self.first {
+		return true
+	}
+	if (self.thereafter > 0) &&
+		((counter.count-self.first)%self.thereafter == 0) {
+		return true
+	}
+	self.suppress(key, record)
+	return false
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestRateLimitFilter(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelDebug))
+	defer manager.Shutdown()
+	logger := manager.GetLogger("a")
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+	filter := NewRateLimitFilter(0.001, 2, time.Millisecond*100)
+	AttachFilter(logger, filter)
+	for i := 0; i < 5; i++ {
+		logger.Infof("message %d", i)
+	}
+	logger.Warn("message")
+	for _, message := range []string{"message 0", "message 1", "message"} {
+		record, err := handler.GetEmitOnTimeout(time.Millisecond * 10)
+		require.Nil(t, err)
+		require.Equal(t, message, record.GetMessage())
+	}
+	_, err := handler.GetEmitOnTimeout(time.Millisecond * 10)
+	require.Equal(t, ErrorTimeout, err)
+	require.Equal(t, uint64(3), filter.GetSuppressed())
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, LevelInfo, record.Level)
+	require.Equal(t,
+		"3 records suppressed by rate limit in last 100ms, the last one: "+
+			"message 4",
+		record.GetMessage())
+	suppressed, ok := record.Fields.Get("suppressed")
+	require.True(t, ok)
+	require.Equal(t, uint64(3), suppressed)
+}
+
+func TestSamplingFilter(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelDebug))
+	defer manager.Shutdown()
+	logger := manager.GetLogger("a")
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+	filter := NewSamplingFilter(2, 3, time.Hour, 0)
+	AttachFilter(handler, filter)
+	for i := 0; i < 9; i++ {
+		logger.Infof("message %d", i)
+	}
+	for _, i := range []int{0, 1, 4, 7} {
+		record, err := handler.GetEmitOnTimeout(time.Millisecond * 10)
+		require.Nil(t, err)
+		require.Equal(t, fmt.Sprintf("message %d", i), record.GetMessage())
+	}
+	_, err := handler.GetEmitOnTimeout(time.Millisecond * 10)
+	require.Equal(t, ErrorTimeout, err)
+	require.Equal(t, uint64(5), filter.GetSuppressed())
+}
+
+func TestConfigRateFilters(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Filters: map[string]ConfFilter{
+			"rate": {Type: "rateLimit", Rate: 10, Burst: 5},
+			"sample": {
+				Type:            "sampling",
+				First:           1,
+				Interval:        1000,
+				SummaryInterval: 60000,
+			},
+		},
+		Loggers: map[string]ConfMap{
+			"a": {"filters": []interface{}{"rate", "sample"}},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	filters := manager.GetLogger("a").(*StandardLogger).GetFilters()
+	require.Equal(t, 2, filters.Len())
+	rate, ok := filters.Front().Value.(*RateLimitFilter)
+	require.True(t, ok)
+	require.Equal(t, "RateLimitFilter(rate=10, burst=5)", rate.String())
+	sample, ok := filters.Back().Value.(*SamplingFilter)
+	require.True(t, ok)
+	require.Equal(t,
+		"SamplingFilter(first=1, thereafter=0, interval=1s)", sample.String())
+
+	conf.Filters["dup"] = ConfFilter{Type: "duplicate", Interval: 1000}
+	require.Nil(t, manager.DictConfig(conf))
+	conf.Filters["bad"] = ConfFilter{Type: "sampling"}
+	require.NotNil(t, manager.DictConfig(conf))
+	conf.Filters["bad"] = ConfFilter{Type: "unknown"}
+	require.NotNil(t, manager.DictConfig(conf))
+}
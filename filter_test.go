@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"encoding/json"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func newFilterTestRecord(
+	name string, level LogLevelType, message string) *LogRecord {
+
+	return NewLogRecord(
+		name, level, "", "", 0, "", message, false, []interface{}{message})
+}
+
+func TestNameFilter(t *testing.T) {
+	filter := NewNameFilter("a.b")
+	require.True(t, filter.Filter(newFilterTestRecord("a.b", LevelInfo, "")))
+	require.True(t, filter.Filter(newFilterTestRecord("a.b.c", LevelInfo, "")))
+	require.False(t, filter.Filter(newFilterTestRecord("a.bb", LevelInfo, "")))
+	require.False(t, filter.Filter(newFilterTestRecord("a", LevelInfo, "")))
+}
+
+func TestLevelRangeFilter(t *testing.T) {
+	filter := NewLevelRangeFilter(LevelInfo, LevelWarn)
+	require.False(t, filter.Filter(newFilterTestRecord("a", LevelDebug, "")))
+	require.True(t, filter.Filter(newFilterTestRecord("a", LevelInfo, "")))
+	require.True(t, filter.Filter(newFilterTestRecord("a", LevelWarn, "")))
+	require.False(t, filter.Filter(newFilterTestRecord("a", LevelError, "")))
+	filter = NewLevelRangeFilter(LevelError, LevelNotset)
+	require.False(t, filter.Filter(newFilterTestRecord("a", LevelWarn, "")))
+	require.True(t, filter.Filter(newFilterTestRecord("a", LevelFatal, "")))
+}
+
+func TestRegexFilters(t *testing.T) {
+	re := regexp.MustCompile("^GET /health")
+	record1 := newFilterTestRecord("http.access", LevelInfo, "GET /health 200")
+	record2 := newFilterTestRecord("db", LevelInfo, "GET /users 200")
+	filter := NewMessageRegexFilter(re, false)
+	require.True(t, filter.Filter(record1))
+	require.False(t, filter.Filter(record2))
+	filter = NewMessageRegexFilter(re, true)
+	require.False(t, filter.Filter(record1))
+	require.True(t, filter.Filter(record2))
+
+	re = regexp.MustCompile(`^http\.`)
+	nameFilter := NewNameRegexFilter(re, false)
+	require.True(t, nameFilter.Filter(record1))
+	require.False(t, nameFilter.Filter(record2))
+	nameFilter = NewNameRegexFilter(re, true)
+	require.False(t, nameFilter.Filter(record1))
+	require.True(t, nameFilter.Filter(record2))
+}
+
+func TestFieldFilter(t *testing.T) {
+	record := newFilterTestRecord("a", LevelInfo, "")
+	record.Fields = NewFields("status", 200, "user", "bob")
+	require.True(t, NewFieldFilter("status", "200", false).Filter(record))
+	require.True(t, NewFieldFilter("status", 200, false).Filter(record))
+	require.False(t, NewFieldFilter("status", 404, false).Filter(record))
+	require.False(t, NewFieldFilter("missing", "", false).Filter(record))
+	require.False(t, NewFieldFilter("user", "bob", true).Filter(record))
+	require.True(t, NewFieldFilter("missing", "", true).Filter(record))
+
+	// integers in JSON config are compared as is rather than as float64
+	var conf ConfFilter
+	require.Nil(t, json.Unmarshal(
+		[]byte(`{"type": "field", "key": "id", "value": 1234567}`), &conf))
+	filter, err := newConfigFilter(conf, NewConfigEnv())
+	require.Nil(t, err)
+	require.Equal(t, "FieldFilter(id=1234567, exclude=false)",
+		filter.(*FieldFilter).String())
+	record.Fields = NewFields("id", 1234567)
+	require.True(t, filter.Filter(record))
+}
+
+func TestStandardFilterer_Concurrent(t *testing.T) {
+	filterer := NewStandardFilterer()
+	filter := NewNameFilter("a")
+	record := newFilterTestRecord("a", LevelInfo, "")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				require.Equal(t, 1, filterer.Filter(record))
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		filterer.AddFilter(filter)
+		filterer.RemoveFilter(filter)
+	}
+	wg.Wait()
+	require.Equal(t, 0, filterer.GetFilters().Len())
+}
+
+func TestConfigFilters(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Filters: map[string]ConfFilter{
+			"range": {Type: "levelRange", Min: "info", Max: "WARN"},
+			"message": {
+				Type:    "messageRegex",
+				Pattern: "ping",
+				Exclude: true,
+			},
+			"name":  {Type: "nameRegex", Pattern: `^db\.`},
+			"field": {Type: "field", Key: "status", Value: 200},
+		},
+		Loggers: map[string]ConfMap{
+			"a": {"filters": []interface{}{"range", "message", "name", "field"}},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	filters := manager.GetLogger("a").(*StandardLogger).GetFilters()
+	require.Equal(t, 4, filters.Len())
+	e := filters.Front()
+	require.Equal(t, "LevelRangeFilter(INFO, WARN)",
+		e.Value.(*LevelRangeFilter).String())
+	e = e.Next()
+	require.Equal(t, `MessageRegexFilter("ping", exclude=true)`,
+		e.Value.(*MessageRegexFilter).String())
+	e = e.Next()
+	require.Equal(t, `NameRegexFilter("^db\\.", exclude=false)`,
+		e.Value.(*NameRegexFilter).String())
+	e = e.Next()
+	require.Equal(t, "FieldFilter(status=200, exclude=false)",
+		e.Value.(*FieldFilter).String())
+
+	for _, bad := range []ConfFilter{
+		{Type: "levelRange", Min: "unknown"},
+		{Type: "levelRange", Min: "ERROR", Max: "INFO"},
+		{Type: "nameRegex", Pattern: "("},
+		{Type: "field"},
+	} {
+		conf.Filters["bad"] = bad
+		require.NotNil(t, manager.DictConfig(conf))
+	}
+}
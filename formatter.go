@@ -2,9 +2,13 @@ package logging
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/hhkbp2/go-strftime"
 	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
 )
 
 // Function type of extracting the corresponding LogRecord info for
@@ -44,8 +48,55 @@ var (
 		"%(message)s": func(record *LogRecord) string {
 			return record.Message
 		},
+		"%(fields)s": func(record *LogRecord) string {
+			return record.Fields.String()
+		},
+		"%(error)s": func(record *LogRecord) string {
+			if record.Err == nil {
+				return ""
+			}
+			return record.Err.Error()
+		},
+		"%(stack)s": func(record *LogRecord) string {
+			return record.Stack
+		},
+		"%(process)d": func(record *LogRecord) string {
+			return strconv.Itoa(record.GetProcess())
+		},
+		"%(hostname)s": func(record *LogRecord) string {
+			return record.GetHostname()
+		},
+		"%(executable)s": func(record *LogRecord) string {
+			return record.GetExecutable()
+		},
+		"%(goroutine)d": func(record *LogRecord) string {
+			return strconv.FormatUint(record.GetGoroutine(), 10)
+		},
+		"%(thread)d": func(record *LogRecord) string {
+			return strconv.FormatUint(record.GetGoroutine(), 10)
+		},
+		"%(relativeCreated)d": func(record *LogRecord) string {
+			return strconv.FormatInt(record.GetRelativeCreated(), 10)
+		},
+		"%(msecs)d": func(record *LogRecord) string {
+			return strconv.Itoa(record.GetMsecs())
+		},
+		"%(msecs)03d": func(record *LogRecord) string {
+			return fmt.Sprintf("%03d", record.GetMsecs())
+		},
 	}
 	formatRe = initFormatRegexp()
+	// The lock of attrToFunc and formatRe.
+	attrLock sync.RWMutex
+
+	// The attributes which should be captured when a record is created,
+	// rather than when it's formatted.
+	goroutineAttrs = map[string]bool{
+		"%(goroutine)d": true,
+		"%(thread)d":    true,
+	}
+
+	attrRe = regexp.MustCompile(`^%\([A-Za-z_][A-Za-z0-9_.]*\)[0-9]*[a-z]$`)
 
 	// Default format strings.
 	defaultFormat     = "%(message)s"
@@ -66,8 +117,36 @@ func initFormatRegexp() *regexp.Regexp {
 	return regexp.MustCompile(re)
 }
 
+// Register the function to extract the attribute of records, so that
+// the attribute could be used in the format of StandardFormatter, e.g.
+//     logging.RegisterFormatAttr("%(user)s", func(r *LogRecord) string {
+//         user, _ := r.Fields.Get("user")
+//         return fmt.Sprint(user)
+//     })
+// The attribute is in the form of "%(name)" followed by a conversion like
+// "s" or "05d", which is part of the attribute and is not interpreted.
+// Any attribute registered before, including the predefined one, is
+// replaced. Only the formatters created after registering are affected.
+func RegisterFormatAttr(attr string, extract ExtractAttr) error {
+	if !attrRe.MatchString(attr) {
+		return errors.New(fmt.Sprintf("invalid attribute: %s", attr))
+	}
+	if extract == nil {
+		return errors.New(fmt.Sprintf("nil function for attribute: %s", attr))
+	}
+	attrLock.Lock()
+	defer attrLock.Unlock()
+	attrToFunc[attr] = extract
+	formatRe = initFormatRegexp()
+	return nil
+}
+
 type GetFormatArgsFunc func(record *LogRecord) []interface{}
 
+// Function type of decorating the value of an attribute string, e.g.
+// "%(levelname)s", as it's substituted into the formatted text.
+type DecorateAttr func(attr string, value string) string
+
 // Formatter interface is for converting a LogRecord to text.
 // Formatters need to know how a LogRecord is constructed. They are responsible
 // for converting a LogRecord to (usually) a string which can be interpreted
@@ -76,6 +155,15 @@ type Formatter interface {
 	Format(record *LogRecord) string
 }
 
+// Formatters which could decorate the values of attributes as they are
+// substituted, e.g. to colorize the level name.
+type DecoratingFormatter interface {
+	Formatter
+	// Format the specified record as Format() does, with the value of every
+	// attribute passed through decorate.
+	FormatDecorated(record *LogRecord, decorate DecorateAttr) string
+}
+
 // The standard formatter. It allows a formatting string to be specified.
 // If none is supplied, the default value of "%(message)s" is used.
 //
@@ -98,9 +186,29 @@ type Formatter interface {
 // %(asctime)s         Textual time when LogRecord was created
 // %(message)s         The result of record.GetMessage(), computed just as the
 //                     record is emitted
+// %(fields)s          Structured fields of the record in the form of
+//                     "key1=value1 key2=value2"
+// %(error)s           The error passed to Logger.ErrorErr() and its siblings
+// %(stack)s           The stack of the logging call, if it's captured
+// %(process)d         Process ID
+// %(hostname)s        Host name
+// %(executable)s      File name of the executable of the process
+// %(goroutine)d       ID of the goroutine which issued the logging call
+// %(thread)d          Same as %(goroutine)d
+// %(relativeCreated)d Time in milliseconds when the LogRecord was created,
+//                     relative to the start of the process
+// %(msecs)d           Millisecond portion of the creation time, and
+//                     %(msecs)03d for the one padded to 3 digits
+//
+// More attributes could be added by RegisterFormatAttr(). The goroutine ID
+// is captured by the logging call only if a formatter referencing it has
+// been created, and the host name and executable are looked up once when
+// they are first formatted.
 type StandardFormatter struct {
 	format            string
 	strFormat         string
+	attrs             []string
+	funs              []ExtractAttr
 	getFormatArgsFunc GetFormatArgsFunc
 	toFormatTime      bool
 	dateFormat        string
@@ -122,16 +230,23 @@ func NewStandardFormatter(format string, dateFormat string) *StandardFormatter {
 		size++
 		return "%s"
 	}
+	attrLock.RLock()
 	strFormat := formatRe.ReplaceAllStringFunc(format, f1)
+	attrs := make([]string, 0, size)
 	funs := make([]ExtractAttr, 0, size)
 	f2 := func(match string) string {
 		extractFunc, ok := attrToFunc[match]
 		if ok {
+			attrs = append(attrs, match)
 			funs = append(funs, extractFunc)
 		}
+		if goroutineAttrs[match] {
+			atomic.StoreInt32(&captureGoroutine, 1)
+		}
 		return match
 	}
 	formatRe.ReplaceAllStringFunc(format, f2)
+	attrLock.RUnlock()
 	getFormatArgsFunc := func(record *LogRecord) []interface{} {
 		result := make([]interface{}, 0, len(funs))
 		for _, f := range funs {
@@ -146,6 +261,8 @@ func NewStandardFormatter(format string, dateFormat string) *StandardFormatter {
 	return &StandardFormatter{
 		format:            format,
 		strFormat:         strFormat + "\n",
+		attrs:             attrs,
+		funs:              funs,
 		getFormatArgsFunc: getFormatArgsFunc,
 		toFormatTime:      toFormatTime,
 		dateFormat:        dateFormat,
@@ -178,6 +295,22 @@ func (self *StandardFormatter) Format(record *LogRecord) string {
 	return self.FormatAll(record)
 }
 
+// Format the specified record as Format() does, with the value of every
+// attribute passed through decorate.
+func (self *StandardFormatter) FormatDecorated(
+	record *LogRecord, decorate DecorateAttr) string {
+
+	record.GetMessage()
+	if self.toFormatTime {
+		record.AscTime = self.FormatTime(record)
+	}
+	args := make([]interface{}, 0, len(self.funs))
+	for i, f := range self.funs {
+		args = append(args, decorate(self.attrs[i], f(record)))
+	}
+	return fmt.Sprintf(self.strFormat, args...)
+}
+
 // Helper function using regexp to replace every valid format attribute string
 // to the record's specific value.
 func (self *StandardFormatter) FormatAll(record *LogRecord) string {
@@ -0,0 +1,329 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hhkbp2/go-strftime"
+)
+
+// The attributes of a record which could be included by JSONFormatter:
+//
+// time        Time when the record was created, in the time format
+// level       Text logging level
+// levelno     Numeric logging level
+// name        Name of the logger
+// pathname    Full pathname of the source file of the logging call
+// filename    Filename portion of pathname
+// lineno      Source line number of the logging call
+// funcname    Function name of the logging call
+// message     The result of record.GetMessage()
+// error       The error of the record, omitted if there is none
+// stack       The stack of the logging call, omitted if it's not captured
+// fields      Structured fields of the record
+var JSONFormatterAttrs = []string{
+	"time", "level", "levelno", "name", "pathname", "filename", "lineno",
+	"funcname", "message", "error", "stack", "fields",
+}
+
+// The attributes included by JSONFormatter by default.
+var DefaultJSONFormatterAttrs = []string{
+	"time", "level", "name", "message", "error", "stack", "fields",
+}
+
+// Options for JSONFormatter.
+type JSONFormatterOptions struct {
+	// The attributes to include in the order of output, which are in
+	// JSONFormatterAttrs. DefaultJSONFormatterAttrs is used if it's nil.
+	Attrs []string
+	// The keys of attributes in output, e.g. {"time": "@timestamp"}.
+	// An attribute is output with its name if it's not in Keys. The fields
+	// are merged at the end of the output object unless a key is set for
+	// them, in which case they are output as an object of the key.
+	Keys map[string]string
+	// The format of time, which is one of:
+	//     "" or "rfc3339"  RFC 3339 format with the fraction of precision
+	//     "unix"           a number of time units of precision since epoch
+	//     others           a strftime format like "%Y-%m-%d %H:%M:%S.%3n",
+	//                      without regard to precision
+	TimeFormat string
+	// The precision of time, which is one of "s", "ms", "us" and "ns".
+	// It's "ms" if it's empty.
+	TimePrecision string
+	// Whether to output time in UTC rather than local time.
+	UTC bool
+}
+
+// A formatter which formats a record as a JSON object in one line, with
+// the chosen attributes of the record and its structured fields.
+//
+// A field of the same key as any attribute in output is output with the key
+// prefixed by "fields.". A field whose value couldn't be encoded in JSON
+// is output as the string of its default format.
+type JSONFormatter struct {
+	attrs     []string
+	keys      []string
+	fieldsKey string
+	time      *recordTimeFormatter
+}
+
+var recordTimeUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+var recordTimeLayouts = map[time.Duration]string{
+	time.Second:      "2006-01-02T15:04:05Z07:00",
+	time.Millisecond: "2006-01-02T15:04:05.000Z07:00",
+	time.Microsecond: "2006-01-02T15:04:05.000000Z07:00",
+	time.Nanosecond:  "2006-01-02T15:04:05.000000000Z07:00",
+}
+
+// The formatter of the creation time of records, in the time format and
+// precision as in JSONFormatterOptions.
+type recordTimeFormatter struct {
+	unit          time.Duration
+	layout        string
+	dateFormatter *strftime.Formatter
+	utc           bool
+}
+
+func newRecordTimeFormatter(
+	format, precision string, utc bool) (*recordTimeFormatter, error) {
+
+	if len(precision) == 0 {
+		precision = "ms"
+	}
+	unit, ok := recordTimeUnits[precision]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(
+			"unknown time precision: %s", precision))
+	}
+	object := &recordTimeFormatter{
+		unit: unit,
+		utc:  utc,
+	}
+	switch format {
+	case "", "rfc3339":
+		object.layout = recordTimeLayouts[unit]
+	case "unix":
+	default:
+		object.dateFormatter = strftime.NewFormatter(format)
+	}
+	return object, nil
+}
+
+// Return the formatted time, and whether it's a number.
+func (self *recordTimeFormatter) Format(t time.Time) (string, bool) {
+	if self.utc {
+		t = t.UTC()
+	}
+	switch {
+	case len(self.layout) > 0:
+		return t.Format(self.layout), false
+	case self.dateFormatter != nil:
+		return self.dateFormatter.Format(t), false
+	default:
+		return strconv.FormatInt(t.UnixNano()/int64(self.unit), 10), true
+	}
+}
+
+// Initialize a JSON formatter with opts, or the default options if opts
+// is nil.
+func NewJSONFormatter(opts *JSONFormatterOptions) (*JSONFormatter, error) {
+	if opts == nil {
+		opts = &JSONFormatterOptions{}
+	}
+	attrs := opts.Attrs
+	if attrs == nil {
+		attrs = DefaultJSONFormatterAttrs
+	}
+	object := &JSONFormatter{
+		attrs: make([]string, 0, len(attrs)),
+		keys:  make([]string, 0, len(attrs)),
+	}
+	for attr := range opts.Keys {
+		if !isJSONFormatterAttr(attr) {
+			return nil, errors.New(fmt.Sprintf("unknown attribute: %s", attr))
+		}
+	}
+	for _, attr := range attrs {
+		if !isJSONFormatterAttr(attr) {
+			return nil, errors.New(fmt.Sprintf("unknown attribute: %s", attr))
+		}
+		key, ok := opts.Keys[attr]
+		if !ok {
+			key = attr
+		}
+		if (attr == "fields") && ok {
+			object.fieldsKey = key
+		}
+		object.attrs = append(object.attrs, attr)
+		object.keys = append(object.keys, key)
+	}
+	timeFormatter, err := newRecordTimeFormatter(
+		opts.TimeFormat, opts.TimePrecision, opts.UTC)
+	if err != nil {
+		return nil, err
+	}
+	object.time = timeFormatter
+	return object, nil
+}
+
+func isJSONFormatterAttr(attr string) bool {
+	for _, a := range JSONFormatterAttrs {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// Format the specified record as a JSON object in one line.
+func (self *JSONFormatter) Format(record *LogRecord) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	writeKey := func(key string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJSONValue(&buf, key)
+		buf.WriteByte(':')
+	}
+	var used map[string]bool
+	var fields Fields
+	mergeFields := false
+	for i, attr := range self.attrs {
+		key := self.keys[i]
+		switch attr {
+		case "time":
+			writeKey(key)
+			if t, isNumber := self.time.Format(record.CreatedTime); isNumber {
+				buf.WriteString(t)
+			} else {
+				writeJSONValue(&buf, t)
+			}
+		case "level":
+			writeKey(key)
+			writeJSONValue(&buf, GetLevelName(record.Level))
+		case "levelno":
+			writeKey(key)
+			buf.WriteString(strconv.Itoa(int(record.Level)))
+		case "name":
+			writeKey(key)
+			writeJSONValue(&buf, record.Name)
+		case "pathname":
+			writeKey(key)
+			writeJSONValue(&buf, record.PathName)
+		case "filename":
+			writeKey(key)
+			writeJSONValue(&buf, record.FileName)
+		case "lineno":
+			writeKey(key)
+			buf.WriteString(strconv.FormatUint(uint64(record.LineNo), 10))
+		case "funcname":
+			writeKey(key)
+			writeJSONValue(&buf, record.FuncName)
+		case "message":
+			writeKey(key)
+			writeJSONValue(&buf, record.GetMessage())
+		case "error":
+			if record.Err == nil {
+				continue
+			}
+			writeKey(key)
+			writeJSONValue(&buf, record.Err.Error())
+		case "stack":
+			if len(record.Stack) == 0 {
+				continue
+			}
+			writeKey(key)
+			writeJSONValue(&buf, record.Stack)
+		case "fields":
+			if len(record.Fields) == 0 {
+				continue
+			}
+			if len(self.fieldsKey) > 0 {
+				writeKey(key)
+				writeJSONFields(&buf, record.Fields)
+				continue
+			}
+			fields = record.Fields
+			mergeFields = true
+			continue
+		}
+		if used == nil {
+			used = make(map[string]bool, len(self.attrs))
+		}
+		used[key] = true
+	}
+	if mergeFields {
+		if !first {
+			buf.WriteByte(',')
+		}
+		writeJSONFieldsBody(&buf, fields, used)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// Write the fields as a JSON object.
+func writeJSONFields(buf *bytes.Buffer, fields Fields) {
+	buf.WriteByte('{')
+	writeJSONFieldsBody(buf, fields, nil)
+	buf.WriteByte('}')
+}
+
+// Write the fields as the members of a JSON object. Only the last one of
+// the fields of the same key is written, and a field whose key is in used
+// is written with the key prefixed by "fields.".
+func writeJSONFieldsBody(
+	buf *bytes.Buffer, fields Fields, used map[string]bool) {
+
+	last := make(map[string]int, len(fields))
+	for i, field := range fields {
+		last[field.Key] = i
+	}
+	first := true
+	for i, field := range fields {
+		if last[field.Key] != i {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key := field.Key
+		if used[key] {
+			key = "fields." + key
+		}
+		writeJSONValue(buf, key)
+		buf.WriteByte(':')
+		value := field.Value
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		writeJSONValue(buf, value)
+	}
+}
+
+// Write the value in JSON without escaping HTML characters, or the string
+// of its default format if it couldn't be encoded in JSON.
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		out.Reset()
+		encoder.Encode(fmt.Sprint(value))
+	}
+	buf.Write(bytes.TrimRight(out.Bytes(), "\n"))
+}
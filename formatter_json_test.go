@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func newJSONTestRecord() *LogRecord {
+	record := NewLogRecord("a.b", LevelWarn, "/src/a.go", "a.go", 12, "a.F",
+		"%s", true, []interface{}{"say \"hi\" <b>\n"})
+	record.CreatedTime = time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	return record
+}
+
+func TestJSONFormatter(t *testing.T) {
+	formatter, err := NewJSONFormatter(nil)
+	require.Nil(t, err)
+	record := newJSONTestRecord()
+	record.Fields = NewFields(
+		"user", "bob", "n", 1, "name", "x", "err", errors.New("e"), "n", 2)
+	require.Equal(t,
+		`{"time":"2024-05-06T07:08:09.123Z","level":"WARN","name":"a.b",`+
+			`"message":"say \"hi\" <b>\n","user":"bob","fields.name":"x",`+
+			`"err":"e","n":2}`+"\n",
+		formatter.Format(record))
+
+	record.Err = errors.New("failed")
+	record.Fields = nil
+	formatter, err = NewJSONFormatter(&JSONFormatterOptions{
+		Attrs: []string{
+			"time", "levelno", "filename", "lineno", "funcname", "error",
+			"stack", "fields",
+		},
+		Keys:          map[string]string{"time": "@timestamp", "error": "err"},
+		TimeFormat:    "unix",
+		TimePrecision: "us",
+	})
+	require.Nil(t, err)
+	require.Equal(t,
+		`{"@timestamp":1714979289123456,"levelno":30,"filename":"a.go",`+
+			`"lineno":12,"funcname":"a.F","err":"failed"}`+"\n",
+		formatter.Format(record))
+}
+
+func TestJSONFormatter_Fields(t *testing.T) {
+	formatter, err := NewJSONFormatter(&JSONFormatterOptions{
+		Attrs:      []string{"time", "message", "fields"},
+		Keys:       map[string]string{"fields": "fields"},
+		TimeFormat: "%Y-%m-%d %H:%M:%S",
+		UTC:        true,
+	})
+	require.Nil(t, err)
+	record := newJSONTestRecord()
+	record.Fields = NewFields("message", "m", "ch", make(chan int), "list",
+		[]int{1, 2})
+	output := formatter.Format(record)
+	var m map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte(output), &m))
+	require.Equal(t, "2024-05-06 07:08:09", m["time"])
+	fields, ok := m["fields"].(map[string]interface{})
+	require.True(t, ok)
+	require.Equal(t, "m", fields["message"])
+	require.Equal(t, []interface{}{1.0, 2.0}, fields["list"])
+	_, ok = fields["ch"].(string)
+	require.True(t, ok)
+}
+
+func TestJSONFormatter_Errors(t *testing.T) {
+	_, err := NewJSONFormatter(&JSONFormatterOptions{Attrs: []string{"x"}})
+	require.NotNil(t, err)
+	_, err = NewJSONFormatter(&JSONFormatterOptions{
+		Keys: map[string]string{"x": "y"},
+	})
+	require.NotNil(t, err)
+	_, err = NewJSONFormatter(&JSONFormatterOptions{TimePrecision: "m"})
+	require.NotNil(t, err)
+}
+
+func TestConfigJSONFormatter(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Formatters: map[string]ConfFormatter{
+			"json": {
+				Class: "JSONFormatter",
+				Params: ConfMap{
+					"attrs":         []interface{}{"level", "message"},
+					"keys":          map[interface{}]interface{}{"level": "lvl"},
+					"timePrecision": "s",
+				},
+			},
+		},
+		Handlers: map[string]ConfMap{
+			"h": {"class": "NullHandler", "formatter": "json"},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	handler := manager.GetCloser().GetHandlers()[0]
+	require.Equal(t, `{"lvl":"WARN","message":"say \"hi\" <b>\n"}`+"\n",
+		handler.Format(newJSONTestRecord()))
+	conf.Formatters["json"].Params["attrs"] = []interface{}{"x"}
+	require.NotNil(t, manager.DictConfig(conf))
+}
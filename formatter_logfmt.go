@@ -0,0 +1,214 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// The leading attributes of a record output by LogfmtFormatter by default,
+// in order. They are time, level, logger name and message.
+var DefaultLogfmtFormatterOrder = []string{"time", "level", "name", "message"}
+
+// The keys of attributes output by LogfmtFormatter by default.
+var DefaultLogfmtFormatterKeys = map[string]string{
+	"time":    "time",
+	"level":   "level",
+	"name":    "logger",
+	"message": "msg",
+	"caller":  "caller",
+	"error":   "error",
+}
+
+// Options for LogfmtFormatter.
+type LogfmtFormatterOptions struct {
+	// The leading attributes in the order of output, which are some of
+	// "time", "level", "name" and "message". DefaultLogfmtFormatterOrder
+	// is used if it's nil.
+	Order []string
+	// The keys of attributes in output, e.g. {"message": "message"}, which
+	// override the ones in DefaultLogfmtFormatterKeys. The attributes are
+	// the ones in Order, "caller" and "error".
+	Keys map[string]string
+	// The format and precision of time, and whether to output it in UTC,
+	// as the ones of JSONFormatterOptions.
+	TimeFormat    string
+	TimePrecision string
+	UTC           bool
+	// Whether to omit the caller in the form of "file:line".
+	OmitCaller bool
+}
+
+// A formatter which formats a record in logfmt, i.e. a line of key=value
+// pairs, e.g.
+//     time=2024-05-06T07:08:09.123Z level=INFO logger=db msg="query done"
+//
+// The leading attributes are followed by the caller, the error if there is,
+// and then the structured fields. Values are quoted if they are empty or
+// contain spaces, '=', '"' or control characters, with '"', '\' and control
+// characters escaped. Characters not allowed in keys are replaced by '_'.
+// Only the last one of the fields of the same key is output, and a field of
+// the same key as any attribute in output is output with the key prefixed
+// by "fields.".
+type LogfmtFormatter struct {
+	order      []string
+	keys       map[string]string
+	time       *recordTimeFormatter
+	omitCaller bool
+}
+
+// Initialize a logfmt formatter with opts, or the default options if opts
+// is nil.
+func NewLogfmtFormatter(
+	opts *LogfmtFormatterOptions) (*LogfmtFormatter, error) {
+
+	if opts == nil {
+		opts = &LogfmtFormatterOptions{}
+	}
+	order := opts.Order
+	if order == nil {
+		order = DefaultLogfmtFormatterOrder
+	}
+	for _, attr := range order {
+		switch attr {
+		case "time", "level", "name", "message":
+		default:
+			return nil, errors.New(fmt.Sprintf("unknown attribute: %s", attr))
+		}
+	}
+	keys := make(map[string]string, len(DefaultLogfmtFormatterKeys))
+	for attr, key := range DefaultLogfmtFormatterKeys {
+		keys[attr] = key
+	}
+	for attr, key := range opts.Keys {
+		if _, ok := keys[attr]; !ok {
+			return nil, errors.New(fmt.Sprintf("unknown attribute: %s", attr))
+		}
+		keys[attr] = key
+	}
+	timeFormatter, err := newRecordTimeFormatter(
+		opts.TimeFormat, opts.TimePrecision, opts.UTC)
+	if err != nil {
+		return nil, err
+	}
+	return &LogfmtFormatter{
+		order:      append([]string(nil), order...),
+		keys:       keys,
+		time:       timeFormatter,
+		omitCaller: opts.OmitCaller,
+	}, nil
+}
+
+// Format the specified record as a line of logfmt.
+func (self *LogfmtFormatter) Format(record *LogRecord) string {
+	var buf bytes.Buffer
+	used := make(map[string]bool, len(self.order)+2)
+	write := func(key, value string) {
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		writeLogfmtKey(&buf, key)
+		buf.WriteByte('=')
+		writeLogfmtValue(&buf, value)
+		used[key] = true
+	}
+	for _, attr := range self.order {
+		key := self.keys[attr]
+		switch attr {
+		case "time":
+			t, _ := self.time.Format(record.CreatedTime)
+			write(key, t)
+		case "level":
+			write(key, GetLevelName(record.Level))
+		case "name":
+			write(key, record.Name)
+		case "message":
+			write(key, record.GetMessage())
+		}
+	}
+	if !self.omitCaller {
+		write(self.keys["caller"],
+			fmt.Sprintf("%s:%d", record.FileName, record.LineNo))
+	}
+	if record.Err != nil {
+		write(self.keys["error"], record.Err.Error())
+	}
+	last := make(map[string]int, len(record.Fields))
+	for i, field := range record.Fields {
+		last[field.Key] = i
+	}
+	for i, field := range record.Fields {
+		if last[field.Key] != i {
+			continue
+		}
+		key := field.Key
+		if used[key] {
+			key = "fields." + key
+		}
+		var value string
+		switch v := field.Value.(type) {
+		case nil:
+		case error:
+			value = v.Error()
+		default:
+			value = fmt.Sprint(v)
+		}
+		write(key, value)
+	}
+	buf.WriteByte('\n')
+	return buf.String()
+}
+
+// Write the key with the characters not allowed replaced by '_'.
+func writeLogfmtKey(buf *bytes.Buffer, key string) {
+	if len(key) == 0 {
+		buf.WriteByte('_')
+		return
+	}
+	for _, r := range key {
+		if (r <= ' ') || (r == '=') || (r == '"') || (r == 0x7f) ||
+			(r == utf8.RuneError) {
+			buf.WriteByte('_')
+		} else {
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// Write the value, quoted if it's necessary.
+func writeLogfmtValue(buf *bytes.Buffer, value string) {
+	needQuote := (len(value) == 0)
+	for _, r := range value {
+		if (r <= ' ') || (r == '=') || (r == '"') || (r == 0x7f) ||
+			(r == utf8.RuneError) {
+			needQuote = true
+			break
+		}
+	}
+	if !needQuote {
+		buf.WriteString(value)
+		return
+	}
+	buf.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if (r < ' ') || (r == 0x7f) {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
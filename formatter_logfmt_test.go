@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	formatter, err := NewLogfmtFormatter(nil)
+	require.Nil(t, err)
+	record := newJSONTestRecord()
+	record.Err = errors.New("failed")
+	record.Fields = NewFields("user", "bob smith", "n", 1, "msg", "x",
+		"a=b", "", "nil", nil, "tab", "\t\x01", "n", 2)
+	require.Equal(t,
+		`time=2024-05-06T07:08:09.123Z level=WARN logger=a.b `+
+			`msg="say \"hi\" <b>\n" caller=a.go:12 error=failed `+
+			`user="bob smith" fields.msg=x a_b="" nil="" `+
+			`tab="\t\u0001" n=2`+"\n",
+		formatter.Format(record))
+
+	formatter, err = NewLogfmtFormatter(&LogfmtFormatterOptions{
+		Order:      []string{"level", "message", "time"},
+		Keys:       map[string]string{"message": "message", "time": "ts"},
+		TimeFormat: "unix",
+		OmitCaller: true,
+	})
+	require.Nil(t, err)
+	record.Err = nil
+	record.Fields = nil
+	require.Equal(t,
+		`level=WARN message="say \"hi\" <b>\n" ts=1714979289123`+"\n",
+		formatter.Format(record))
+
+	_, err = NewLogfmtFormatter(&LogfmtFormatterOptions{
+		Order: []string{"caller"},
+	})
+	require.NotNil(t, err)
+	_, err = NewLogfmtFormatter(&LogfmtFormatterOptions{
+		Keys: map[string]string{"x": "y"},
+	})
+	require.NotNil(t, err)
+}
+
+func TestConfigLogfmtFormatter(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Formatters: map[string]ConfFormatter{
+			"logfmt": {
+				Class: "LogfmtFormatter",
+				Params: ConfMap{
+					"order":      []interface{}{"name", "message"},
+					"omitCaller": true,
+				},
+			},
+		},
+		Handlers: map[string]ConfMap{
+			"h": {"class": "NullHandler", "formatter": "logfmt"},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	handler := manager.GetCloser().GetHandlers()[0]
+	require.Equal(t, `logger=a.b msg="say \"hi\" <b>\n"`+"\n",
+		handler.Format(newJSONTestRecord()))
+}
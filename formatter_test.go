@@ -3,7 +3,10 @@ package logging
 import (
 	"fmt"
 	"github.com/hhkbp2/testify/require"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 var (
@@ -79,8 +82,95 @@ func TestFormat_AscTime(t *testing.T) {
 		WithLineFeed(testRecord.AscTime), formatter.Format(testRecord))
 }
 
+func TestFormat_Fields(t *testing.T) {
+	formatter := NewStandardFormatter("%(message)s %(fields)s", "")
+	record := NewLogRecord(
+		"name", LevelInfo, "", "", 0, "", "", false,
+		[]interface{}{"message"})
+	record.Fields = NewFields("a", 1, "b", "xyz")
+	require.Equal(t, WithLineFeed("message a=1 b=xyz"), formatter.Format(record))
+}
+
 func TestFormat_Message(t *testing.T) {
 	formatter := defaultFormatter
 	require.Equal(t,
 		WithLineFeed(testRecord.GetMessage()), formatter.Format(testRecord))
 }
+
+func TestFormat_Process(t *testing.T) {
+	formatter := NewStandardFormatter(
+		"%(process)d %(hostname)s %(executable)s", "")
+	hostname, err := os.Hostname()
+	require.Nil(t, err)
+	executable, err := os.Executable()
+	require.Nil(t, err)
+	require.Equal(t,
+		WithLineFeed(fmt.Sprintf("%d %s %s",
+			os.Getpid(), hostname, filepath.Base(executable))),
+		formatter.Format(testRecord))
+}
+
+func TestFormat_Goroutine(t *testing.T) {
+	formatter := NewStandardFormatter("%(goroutine)d %(thread)d", "")
+	type result struct {
+		record *LogRecord
+		id     uint64
+	}
+	ch := make(chan result)
+	go func() {
+		record := NewLogRecord(
+			"name", LevelInfo, "", "", 0, "", "", false, nil)
+		ch <- result{record, CurrentGoroutineID()}
+	}()
+	r := <-ch
+	require.NotEqual(t, uint64(0), r.id)
+	require.NotEqual(t, CurrentGoroutineID(), r.id)
+	require.Equal(t, WithLineFeed(fmt.Sprintf("%d %d", r.id, r.id)),
+		formatter.Format(r.record))
+}
+
+func TestFormat_Msecs(t *testing.T) {
+	formatter := NewStandardFormatter(
+		"%(relativeCreated)d %(msecs)d %(msecs)03d", "")
+	record := NewLogRecord("name", LevelInfo, "", "", 0, "", "", false, nil)
+	record.CreatedTime = processStartTime.Add(
+		1234*time.Millisecond + 500*time.Microsecond)
+	msecs := record.CreatedTime.Nanosecond() / int(time.Millisecond)
+	require.Equal(t,
+		WithLineFeed(fmt.Sprintf("1234 %d %03d", msecs, msecs)),
+		formatter.Format(record))
+	formatter = NewStandardFormatter("%(msecs)d %(msecs)03d", "")
+	record.CreatedTime = time.Date(2024, 5, 6, 7, 8, 9, 7000000, time.UTC)
+	require.Equal(t, WithLineFeed("7 007"), formatter.Format(record))
+}
+
+func TestRegisterFormatAttr(t *testing.T) {
+	err := RegisterFormatAttr("%(user)s", func(record *LogRecord) string {
+		user, _ := record.Fields.Get("user")
+		return fmt.Sprint(user)
+	})
+	require.Nil(t, err)
+	formatter := NewStandardFormatter("%(user)s: %(message)s", "")
+	record := NewLogRecord(
+		"name", LevelInfo, "", "", 0, "", "", false,
+		[]interface{}{"message"})
+	record.Fields = NewFields("user", "bob")
+	require.Equal(t, WithLineFeed("bob: message"), formatter.Format(record))
+
+	require.NotNil(t, RegisterFormatAttr("user", func(*LogRecord) string {
+		return ""
+	}))
+	require.NotNil(t, RegisterFormatAttr("%(user)", func(*LogRecord) string {
+		return ""
+	}))
+	require.NotNil(t, RegisterFormatAttr("%(user)s", nil))
+}
+
+func TestFormat_Decorated(t *testing.T) {
+	formatter := NewStandardFormatter("%(levelname)s %(message)s", "")
+	decorate := func(attr string, value string) string {
+		return attr + "=" + value
+	}
+	require.Equal(t, WithLineFeed("%(levelname)s=INFO %(message)s=message"),
+		formatter.FormatDecorated(testRecord, decorate))
+}
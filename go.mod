@@ -1,6 +1,6 @@
 module github.com/hhkbp2/go-logging
 
-go 1.12
+go 1.13
 
 require (
 	github.com/hhkbp2/go-strftime v0.0.0-20150709091403-d82166ec6782
@@ -99,6 +99,17 @@ func (self *BaseHandler) SetFormatter(formatter Formatter) {
 	self.formatter = formatter
 }
 
+// Return the formatter used by Format(), which is the default formatter
+// for the module if no formatter is set.
+func (self *BaseHandler) GetFormatter() Formatter {
+	self.formatterLock.RLock()
+	defer self.formatterLock.RUnlock()
+	if self.formatter != nil {
+		return self.formatter
+	}
+	return defaultFormatter
+}
+
 // Acquire a lock for serializing access to the underlying I/O.
 func (self *BaseHandler) Lock() {
 	self.lock.Lock()
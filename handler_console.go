@@ -0,0 +1,247 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// The ANSI color names which could be used in the palette of ConsoleHandler,
+// along with their SGR codes.
+var ConsoleColorNames = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+	"bold":    "1",
+}
+
+// The default palette of ConsoleHandler, from levels to the colors of their
+// names.
+var DefaultConsolePalette = map[LogLevelType]string{
+	LevelTrace: "gray",
+	LevelDebug: "cyan",
+	LevelInfo:  "green",
+	LevelWarn:  "yellow",
+	LevelError: "red",
+	LevelFatal: "bold;red",
+}
+
+// The default color of logger names by ConsoleHandler.
+var DefaultConsoleNameColor = "magenta"
+
+// A handler which writes records to console, i.e. stdout or stderr, with
+// the level names, and optionally the logger names, in the formatted records
+// colorized by ANSI escape codes.
+//
+// Colors are enabled if the console is a terminal, unless the environment
+// variable NO_COLOR is set to a non-empty value. They are enabled as well
+// if FORCE_COLOR is set to a value other than "0" and "false", even if
+// the console is not a terminal. SetColor() overrides them all.
+//
+// The color of a level is looked up in the palette. If it's not there, e.g.
+// a level added by AddLevel() without a color set, the color of the nearest
+// lower level in the palette is used. The level name and the logger name
+// are colorized as they are substituted for "%(levelname)s" and "%(name)s"
+// by the formatter, which should be a DecoratingFormatter such as
+// StandardFormatter. Records are not colorized by other formatters.
+type ConsoleHandler struct {
+	*StreamHandler
+	color     bool
+	colorName bool
+	palette   map[LogLevelType]string
+	nameColor string
+	colorLock sync.RWMutex
+}
+
+// Initialize a console handler to write to file, which is os.Stdout or
+// os.Stderr usually.
+func NewConsoleHandler(file *os.File) *ConsoleHandler {
+	stream := NewWriterStream(file)
+	handler := NewStreamHandler("console", LevelNotset, stream)
+	object := &ConsoleHandler{
+		StreamHandler: handler,
+		color:         detectConsoleColor(file),
+		palette:       make(map[LogLevelType]string),
+	}
+	for level, color := range DefaultConsolePalette {
+		object.palette[level], _ = parseConsoleColor(color)
+	}
+	object.nameColor, _ = parseConsoleColor(DefaultConsoleNameColor)
+	Closer.RemoveHandler(object.StreamHandler)
+	Closer.AddHandler(object)
+	return object
+}
+
+// Report whether colors should be enabled for file by default.
+func detectConsoleColor(file *os.File) bool {
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		return false
+	}
+	if force, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		return (force != "0") && !strings.EqualFold(force, "false")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return (info.Mode() & os.ModeCharDevice) != 0
+}
+
+// Return the SGR codes of the color, which is a list of color names or SGR
+// codes separated by ';', e.g. "bold;red" or "1;31".
+func parseConsoleColor(color string) (string, error) {
+	parts := strings.Split(color, ";")
+	codes := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if code, ok := ConsoleColorNames[strings.ToLower(part)]; ok {
+			codes = append(codes, code)
+			continue
+		}
+		if len(part) == 0 {
+			return "", errors.New(fmt.Sprintf("invalid color: %q", color))
+		}
+		for _, c := range part {
+			if (c < '0') || (c > '9') {
+				return "", errors.New(fmt.Sprintf("invalid color: %q", color))
+			}
+		}
+		codes = append(codes, part)
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+// Report whether colors are enabled.
+func (self *ConsoleHandler) GetColor() bool {
+	self.colorLock.RLock()
+	defer self.colorLock.RUnlock()
+	return self.color
+}
+
+// Enable or disable colors, regardless of the console and the environment.
+func (self *ConsoleHandler) SetColor(color bool) {
+	self.colorLock.Lock()
+	defer self.colorLock.Unlock()
+	self.color = color
+}
+
+// Set whether to colorize logger names.
+func (self *ConsoleHandler) SetColorName(colorName bool) {
+	self.colorLock.Lock()
+	defer self.colorLock.Unlock()
+	self.colorName = colorName
+}
+
+// Set the color of logger names, which is as the one of SetLevelColor().
+func (self *ConsoleHandler) SetNameColor(color string) error {
+	codes, err := parseConsoleColor(color)
+	if err != nil {
+		return err
+	}
+	self.colorLock.Lock()
+	defer self.colorLock.Unlock()
+	self.nameColor = codes
+	return nil
+}
+
+// Set the color of the level name in the palette. The color is a list of
+// color names in ConsoleColorNames or SGR codes separated by ';', e.g.
+// "bold;red" or "1;31". The level is removed from the palette if color is
+// empty.
+func (self *ConsoleHandler) SetLevelColor(
+	level LogLevelType, color string) error {
+
+	if len(color) == 0 {
+		self.colorLock.Lock()
+		defer self.colorLock.Unlock()
+		delete(self.palette, level)
+		return nil
+	}
+	codes, err := parseConsoleColor(color)
+	if err != nil {
+		return err
+	}
+	self.colorLock.Lock()
+	defer self.colorLock.Unlock()
+	self.palette[level] = codes
+	return nil
+}
+
+// Return the SGR codes of the color of the level, or empty if there is
+// no color for it.
+func (self *ConsoleHandler) GetLevelColor(level LogLevelType) string {
+	self.colorLock.RLock()
+	defer self.colorLock.RUnlock()
+	return self.levelColor(level)
+}
+
+// The colorLock should be held.
+func (self *ConsoleHandler) levelColor(level LogLevelType) string {
+	if codes, ok := self.palette[level]; ok {
+		return codes
+	}
+	found := false
+	var nearest LogLevelType
+	for l := range self.palette {
+		if (l < level) && (!found || (l > nearest)) {
+			nearest = l
+			found = true
+		}
+	}
+	if !found {
+		return ""
+	}
+	return self.palette[nearest]
+}
+
+// Emit a record, with colors if they are enabled.
+func (self *ConsoleHandler) Emit(record *LogRecord) error {
+	formatter, ok := self.GetFormatter().(DecoratingFormatter)
+	self.colorLock.RLock()
+	if !self.color || !ok {
+		self.colorLock.RUnlock()
+		return self.GetStream().Write(self.Format(record))
+	}
+	message := formatter.FormatDecorated(record,
+		func(attr string, value string) string {
+			return self.colorize(record, attr, value)
+		})
+	self.colorLock.RUnlock()
+	return self.GetStream().Write(message)
+}
+
+// Colorize the value of the attribute if it's the level name, or the logger
+// name when it's enabled. The colorLock should be held.
+func (self *ConsoleHandler) colorize(
+	record *LogRecord, attr string, value string) string {
+
+	var codes string
+	switch attr {
+	case "%(levelname)s":
+		codes = self.levelColor(record.Level)
+	case "%(name)s":
+		if self.colorName {
+			codes = self.nameColor
+		}
+	}
+	if (len(codes) == 0) || (len(value) == 0) {
+		return value
+	}
+	return "\x1b[" + codes + "m" + value + "\x1b[0m"
+}
+
+func (self *ConsoleHandler) Handle(record *LogRecord) int {
+	return self.Handle2(self, record)
+}
+
+func (self *ConsoleHandler) Close() {
+	self.StreamHandler.Close2()
+}
@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	file, err := ioutil.TempFile("", "console")
+	require.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	handler := NewConsoleHandler(file)
+	defer handler.Close()
+	handler.SetFormatter(
+		NewStandardFormatter("%(levelname)s %(name)s: %(message)s", ""))
+	logger := GetLogger("console")
+	logger.SetLevel(LevelTrace)
+	logger.AddHandler(handler)
+	defer logger.RemoveHandler(handler)
+
+	handler.SetColor(false)
+	logger.Info("INFO console")
+	handler.SetColor(true)
+	logger.Info("INFO console")
+	logger.Error("message")
+	handler.SetColorName(true)
+	logger.Debug("message")
+	require.Nil(t, handler.SetNameColor("bold;34"))
+	logger.Log(LevelInfo+5, "message")
+	require.Nil(t, handler.SetLevelColor(LevelInfo+5, "blue"))
+	logger.Log(LevelInfo+5, "message")
+	require.Nil(t, handler.SetLevelColor(LevelTrace, ""))
+	logger.Trace("message")
+	content, err := ioutil.ReadFile(file.Name())
+	require.Nil(t, err)
+	require.Equal(t,
+		"INFO console: INFO console\n"+
+			"\x1b[32mINFO\x1b[0m console: INFO console\n"+
+			"\x1b[31mERROR\x1b[0m console: message\n"+
+			"\x1b[36mDEBUG\x1b[0m \x1b[35mconsole\x1b[0m: message\n"+
+			"\x1b[32mLevel 25\x1b[0m \x1b[1;34mconsole\x1b[0m: message\n"+
+			"\x1b[34mLevel 25\x1b[0m \x1b[1;34mconsole\x1b[0m: message\n"+
+			"TRACE \x1b[1;34mconsole\x1b[0m: message\n",
+		string(content))
+
+	require.NotNil(t, handler.SetLevelColor(LevelInfo, "purple"))
+	require.NotNil(t, handler.SetNameColor("1;x"))
+}
+
+func TestConsoleHandler_Message(t *testing.T) {
+	file, err := ioutil.TempFile("", "console")
+	require.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	handler := NewConsoleHandler(file)
+	defer handler.Close()
+	handler.SetColor(true)
+	handler.SetColorName(true)
+	logger := GetLogger("console2")
+	logger.AddHandler(handler)
+	defer logger.RemoveHandler(handler)
+
+	// the names in message are never colorized
+	handler.SetFormatter(NewStandardFormatter("%(message)s", ""))
+	logger.Error("ERROR console2")
+	handler.SetFormatter(NewStandardFormatter("%(message)s [%(name)s]", ""))
+	logger.Error("console2 ERROR")
+	formatter, err := NewLogfmtFormatter(&LogfmtFormatterOptions{
+		Order:      []string{"level", "name"},
+		OmitCaller: true,
+	})
+	require.Nil(t, err)
+	handler.SetFormatter(formatter)
+	logger.Error("message")
+	content, err := ioutil.ReadFile(file.Name())
+	require.Nil(t, err)
+	require.Equal(t,
+		"ERROR console2\n"+
+			"console2 ERROR [\x1b[35mconsole2\x1b[0m]\n"+
+			"level=ERROR logger=console2\n",
+		string(content))
+}
+
+func TestConsoleHandler_Env(t *testing.T) {
+	noColor, noColorOK := os.LookupEnv("NO_COLOR")
+	forceColor, forceColorOK := os.LookupEnv("FORCE_COLOR")
+	defer func() {
+		os.Unsetenv("NO_COLOR")
+		os.Unsetenv("FORCE_COLOR")
+		if noColorOK {
+			os.Setenv("NO_COLOR", noColor)
+		}
+		if forceColorOK {
+			os.Setenv("FORCE_COLOR", forceColor)
+		}
+	}()
+	file, err := ioutil.TempFile("", "console")
+	require.Nil(t, err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	os.Unsetenv("NO_COLOR")
+	os.Unsetenv("FORCE_COLOR")
+	require.False(t, detectConsoleColor(file))
+	os.Setenv("FORCE_COLOR", "1")
+	require.True(t, detectConsoleColor(file))
+	os.Setenv("FORCE_COLOR", "0")
+	require.False(t, detectConsoleColor(file))
+	os.Setenv("FORCE_COLOR", "1")
+	os.Setenv("NO_COLOR", "1")
+	require.False(t, detectConsoleColor(file))
+}
+
+func TestConfigConsoleHandler(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Handlers: map[string]ConfMap{
+			"h": {
+				"class":     "ConsoleHandler",
+				"stream":    "stdout",
+				"color":     true,
+				"colorName": true,
+				"palette": map[interface{}]interface{}{
+					"warn": "bold;yellow",
+				},
+			},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	handler, ok := manager.GetCloser().GetHandlers()[0].(*ConsoleHandler)
+	require.True(t, ok)
+	require.True(t, handler.GetColor())
+	require.Equal(t, "1;33", handler.GetLevelColor(LevelWarn))
+
+	for _, m := range []ConfMap{
+		{"class": "ConsoleHandler", "stream": "stdin"},
+		{"class": "ConsoleHandler", "palette": ConfMap{"LOUD": "red"}},
+		{"class": "ConsoleHandler", "palette": ConfMap{"INFO": "purple"}},
+	} {
+		conf.Handlers["h"] = m
+		require.NotNil(t, manager.DictConfig(conf))
+	}
+}
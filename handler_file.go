@@ -154,11 +154,15 @@ func (self *FileHandler) GetFilePath() string {
 // and set it to the underlying stream handler.
 // Return non-nil error if error happens.
 func (self *FileHandler) Open() error {
+	return self.openFile(self.mode)
+}
+
+func (self *FileHandler) openFile(mode int) error {
 	var file *os.File
 	var err error
 	for {
 		file, err = os.OpenFile(
-			self.filepath, os.O_WRONLY|os.O_CREATE|self.mode, 0666)
+			self.filepath, os.O_WRONLY|os.O_CREATE|mode, 0666)
 		if err == nil {
 			break
 		}
@@ -178,6 +182,17 @@ func (self *FileHandler) Open() error {
 	return nil
 }
 
+// Close and open the log file again, e.g. after it's moved away by external
+// tools like logrotate, so that the following logs go to the new file
+// at the same path. The file is always opened for appending, so that it's
+// not truncated if it's not moved away.
+func (self *FileHandler) Reopen() error {
+	self.Lock()
+	defer self.Unlock()
+	self.StreamHandler.Close2()
+	return self.openFile(self.mode&^os.O_TRUNC | os.O_APPEND)
+}
+
 // Emit a record.
 func (self *FileHandler) Emit(record *LogRecord) error {
 	return self.StreamHandler.Emit2(self, record)
@@ -52,3 +52,20 @@ func TestFileHandler_Asctime(t *testing.T) {
 	err = os.Remove(testFileName)
 	require.Nil(t, err)
 }
+
+func TestFileHandler_Reopen(t *testing.T) {
+	handler, err := NewFileHandler(testFileName, os.O_TRUNC, testBufferSize)
+	require.Nil(t, err)
+	defer os.Remove(testFileName)
+	logger := GetLogger("file3")
+	logger.AddHandler(handler)
+	defer logger.RemoveHandler(handler)
+	logger.Errorf("a")
+	// the file is not truncated when it's not moved away
+	require.Nil(t, handler.Reopen())
+	logger.Errorf("b")
+	handler.Close()
+	content, err := ioutil.ReadFile(testFileName)
+	require.Nil(t, err)
+	require.Equal(t, "a\nb\n", string(content))
+}
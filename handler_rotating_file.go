@@ -58,7 +58,7 @@ func (self *BaseRotatingHandler) RolloverEmit(
 	// for performance optimization.
 	doRollover, message := handler.ShouldRollover(record)
 	if doRollover {
-		if err := handler.Flush(); err != nil {
+		if err := self.Flush(); err != nil {
 			return err
 		}
 		if err := handler.DoRollover(); err != nil {
@@ -85,6 +85,8 @@ type RotatingFileHandler struct {
 	inputChanSize   int
 	handleFunc      HandleFunc
 	inputChan       chan *LogRecord
+	flushChan       chan chan error
+	stopChan        chan struct{}
 	group           *sync.WaitGroup
 }
 
@@ -150,6 +152,8 @@ func NewRotatingFileHandler(
 	if inputChanSize > 0 {
 		object.handleFunc = object.handleChan
 		object.inputChan = make(chan *LogRecord, inputChanSize)
+		object.flushChan = make(chan chan error)
+		object.stopChan = make(chan struct{})
 		object.group = &sync.WaitGroup{}
 		object.group.Add(1)
 		go func() {
@@ -262,6 +266,7 @@ func (self *RotatingFileHandler) handleChan(record *LogRecord) int {
 }
 
 func (self *RotatingFileHandler) loop() {
+	defer close(self.stopChan)
 	ticker := time.NewTicker(self.bufferFlushTime)
 	for {
 		select {
@@ -269,12 +274,45 @@ func (self *RotatingFileHandler) loop() {
 			if r == nil {
 				return
 			}
-
 			self.Handle2(self, r)
+		case done := <-self.flushChan:
+			stop := self.drain()
+			done <- self.BaseRotatingHandler.Flush()
+			if stop {
+				return
+			}
 		case <-ticker.C:
-			self.Flush()
+			self.BaseRotatingHandler.Flush()
+		}
+	}
+}
+
+// Handle all the records which are already queued in the input chan.
+// Return true if the "stop signal" is met.
+func (self *RotatingFileHandler) drain() bool {
+	for i := len(self.inputChan); i > 0; i-- {
+		r := <-self.inputChan
+		if r == nil {
+			return true
+		}
+		self.Handle2(self, r)
+	}
+	return false
+}
+
+// Flush the underlying stream.
+// If this handler runs in a standalone go routine, all the records queued
+// so far are handled before flushing.
+func (self *RotatingFileHandler) Flush() error {
+	if self.inputChanSize > 0 {
+		done := make(chan error, 1)
+		select {
+		case self.flushChan <- done:
+			return <-done
+		case <-self.stopChan:
 		}
 	}
+	return self.BaseRotatingHandler.Flush()
 }
 
 func (self *RotatingFileHandler) Handle(record *LogRecord) int {
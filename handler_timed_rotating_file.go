@@ -41,6 +41,8 @@ type TimedRotatingFileHandler struct {
 	inputChanSize   int
 	handleFunc      HandleFunc
 	inputChan       chan *LogRecord
+	flushChan       chan chan error
+	stopChan        chan struct{}
 	group           *sync.WaitGroup
 }
 
@@ -135,6 +137,8 @@ func NewTimedRotatingFileHandler(
 	if inputChanSize > 0 {
 		object.handleFunc = object.handleChan
 		object.inputChan = make(chan *LogRecord, inputChanSize)
+		object.flushChan = make(chan chan error)
+		object.stopChan = make(chan struct{})
 		object.group = &sync.WaitGroup{}
 		object.group.Add(1)
 		go func() {
@@ -308,6 +312,7 @@ func (self *TimedRotatingFileHandler) handleChan(record *LogRecord) int {
 }
 
 func (self *TimedRotatingFileHandler) loop() {
+	defer close(self.stopChan)
 	ticker := time.NewTicker(self.bufferFlushTime)
 	for {
 		select {
@@ -316,10 +321,44 @@ func (self *TimedRotatingFileHandler) loop() {
 				return
 			}
 			self.Handle2(self, r)
+		case done := <-self.flushChan:
+			stop := self.drain()
+			done <- self.BaseRotatingHandler.Flush()
+			if stop {
+				return
+			}
 		case <-ticker.C:
-			self.Flush()
+			self.BaseRotatingHandler.Flush()
+		}
+	}
+}
+
+// Handle all the records which are already queued in the input chan.
+// Return true if the "stop signal" is met.
+func (self *TimedRotatingFileHandler) drain() bool {
+	for i := len(self.inputChan); i > 0; i-- {
+		r := <-self.inputChan
+		if r == nil {
+			return true
+		}
+		self.Handle2(self, r)
+	}
+	return false
+}
+
+// Flush the underlying stream.
+// If this handler runs in a standalone go routine, all the records queued
+// so far are handled before flushing.
+func (self *TimedRotatingFileHandler) Flush() error {
+	if self.inputChanSize > 0 {
+		done := make(chan error, 1)
+		select {
+		case self.flushChan <- done:
+			return <-done
+		case <-self.stopChan:
 		}
 	}
+	return self.BaseRotatingHandler.Flush()
 }
 
 func (self *TimedRotatingFileHandler) Handle(record *LogRecord) int {
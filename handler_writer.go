@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// A class wraps any io.Writer, e.g. a bytes.Buffer, a pipe or a network
+// connection, to the stream interface, with optional buffering.
+//
+// The offset reported by Tell() is the number of bytes written into the
+// stream since it's created, including the ones buffered. The underlying
+// writer is never closed by the stream, since it's owned by the caller.
+type WriterStream struct {
+	writer io.Writer
+	buffer *bufio.Writer
+	offset int64
+	lock   sync.Mutex
+}
+
+// Initialize a stream which writes to writer without buffering.
+func NewWriterStream(writer io.Writer) *WriterStream {
+	return NewBufferedWriterStream(writer, 0)
+}
+
+// Initialize a stream which writes to writer through a buffer of bufferSize
+// bytes. The buffer is not used if bufferSize is not positive.
+func NewBufferedWriterStream(writer io.Writer, bufferSize int) *WriterStream {
+	object := &WriterStream{
+		writer: writer,
+	}
+	if bufferSize > 0 {
+		object.buffer = bufio.NewWriterSize(writer, bufferSize)
+	}
+	return object
+}
+
+// Return the underlying writer.
+func (self *WriterStream) GetWriter() io.Writer {
+	return self.writer
+}
+
+func (self *WriterStream) Tell() (int64, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.offset, nil
+}
+
+func (self *WriterStream) Write(s string) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	var n int
+	var err error
+	if self.buffer != nil {
+		n, err = self.buffer.WriteString(s)
+	} else {
+		n, err = io.WriteString(self.writer, s)
+	}
+	self.offset += int64(n)
+	return err
+}
+
+func (self *WriterStream) Flush() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.buffer != nil {
+		return self.buffer.Flush()
+	}
+	return nil
+}
+
+func (self *WriterStream) Close() error {
+	// Flush the buffer but leave the writer to its owner
+	return self.Flush()
+}
+
+// A handler class which writes formatted logging records to an io.Writer.
+type WriterHandler struct {
+	*StreamHandler
+}
+
+// Initialize a handler which writes to writer through a buffer of
+// bufferSize bytes, or without buffering if bufferSize is not positive.
+// The buffer is flushed when the handler is flushed or closed.
+func NewWriterHandler(writer io.Writer, bufferSize int) *WriterHandler {
+	stream := NewBufferedWriterStream(writer, bufferSize)
+	handler := NewStreamHandler("writer", LevelNotset, stream)
+	object := &WriterHandler{
+		StreamHandler: handler,
+	}
+	Closer.RemoveHandler(object.StreamHandler)
+	Closer.AddHandler(object)
+	return object
+}
+
+func (self *WriterHandler) Emit(record *LogRecord) error {
+	return self.StreamHandler.Emit2(self, record)
+}
+
+func (self *WriterHandler) Handle(record *LogRecord) int {
+	return self.Handle2(self, record)
+}
+
+func (self *WriterHandler) Close() {
+	self.StreamHandler.Close2()
+}
+
+// A handler class which writes formatted logging records to stderr.
+type StderrHandler struct {
+	*StreamHandler
+}
+
+func NewStderrHandler() *StderrHandler {
+	stream := NewWriterStream(os.Stderr)
+	handler := NewStreamHandler("stderr", LevelNotset, stream)
+	object := &StderrHandler{
+		StreamHandler: handler,
+	}
+	Closer.RemoveHandler(object.StreamHandler)
+	Closer.AddHandler(object)
+	return object
+}
+
+func (self *StderrHandler) Emit(record *LogRecord) error {
+	return self.StreamHandler.Emit2(self, record)
+}
+
+func (self *StderrHandler) Handle(record *LogRecord) int {
+	return self.Handle2(self, record)
+}
+
+func (self *StderrHandler) Close() {
+	self.StreamHandler.Close2()
+}
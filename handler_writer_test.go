@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+type failingWriter struct {
+	n int
+}
+
+func (self *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > self.n {
+		return self.n, errors.New("short write")
+	}
+	return len(p), nil
+}
+
+func TestWriterStream(t *testing.T) {
+	var buf bytes.Buffer
+	stream := NewWriterStream(&buf)
+	require.Nil(t, stream.Write("abc"))
+	require.Nil(t, stream.Write("de\n"))
+	require.Equal(t, "abcde\n", buf.String())
+	offset, err := stream.Tell()
+	require.Nil(t, err)
+	require.Equal(t, int64(6), offset)
+	require.Nil(t, stream.Close())
+
+	stream = NewWriterStream(&failingWriter{n: 2})
+	require.NotNil(t, stream.Write("abc"))
+	offset, _ = stream.Tell()
+	require.Equal(t, int64(2), offset)
+}
+
+func TestWriterStream_Buffered(t *testing.T) {
+	var buf bytes.Buffer
+	stream := NewBufferedWriterStream(&buf, 8)
+	require.Nil(t, stream.Write("abcd"))
+	require.Equal(t, "", buf.String())
+	offset, _ := stream.Tell()
+	require.Equal(t, int64(4), offset)
+	require.Nil(t, stream.Write("efghij"))
+	require.Equal(t, "abcdefgh", buf.String())
+	require.Nil(t, stream.Flush())
+	require.Equal(t, "abcdefghij", buf.String())
+	offset, _ = stream.Tell()
+	require.Equal(t, int64(10), offset)
+}
+
+func TestWriterHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewWriterHandler(&buf, 1024)
+	logger := GetLogger("writer")
+	logger.AddHandler(handler)
+	defer logger.RemoveHandler(handler)
+	logger.Errorf("test %d", 1)
+	require.Equal(t, "", buf.String())
+	require.Nil(t, handler.Flush())
+	require.Equal(t, "test 1\n", buf.String())
+	logger.Errorf("test %d", 2)
+	handler.Close()
+	require.Equal(t, "test 1\ntest 2\n", buf.String())
+}
+
+func TestStderrHandler(_ *testing.T) {
+	logger := GetLogger("stderr")
+	handler := NewStderrHandler()
+	logger.AddHandler(handler)
+	defer logger.RemoveHandler(handler)
+	logger.Warnf("test message")
+}
+
+func TestConfigWriterHandler(t *testing.T) {
+	var buf bytes.Buffer
+	RegisterConfigWriter("test.buffer", &buf)
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	conf := &Conf{
+		Handlers: map[string]ConfMap{
+			"w": {"class": "WriterHandler", "writer": "test.buffer"},
+			"e": {"class": "StderrHandler", "level": "FATAL"},
+		},
+		Root: ConfMap{
+			"handlers": []interface{}{"w", "e"},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	manager.GetLogger("a").Error("message")
+	require.Equal(t, "message\n", buf.String())
+
+	conf.Handlers["w"] = ConfMap{"class": "WriterHandler", "writer": "none"}
+	require.NotNil(t, manager.DictConfig(conf))
+	conf.Handlers["w"] = ConfMap{"class": "WriterHandler"}
+	require.NotNil(t, manager.DictConfig(conf))
+}
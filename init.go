@@ -31,6 +31,30 @@ func (self *HandlerCloser) RemoveHandler(handler Handler) {
 	}
 }
 
+// Return all handlers registered, in order of their registration.
+func (self *HandlerCloser) GetHandlers() []Handler {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	handlers := make([]Handler, 0, self.handlers.Len())
+	for e := self.handlers.Front(); e != nil; e = e.Next() {
+		handler, _ := e.Value.(Handler)
+		handlers = append(handlers, handler)
+	}
+	return handlers
+}
+
+// Flush all handlers, in the reverse order of their registration so that
+// a handler which forwards records to another one, e.g. MemoryHandler,
+// is flushed before its target.
+func (self *HandlerCloser) Flush() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for e := self.handlers.Back(); e != nil; e = e.Prev() {
+		handler, _ := e.Value.(Handler)
+		handler.Flush()
+	}
+}
+
 func (self *HandlerCloser) Close() {
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -53,7 +77,13 @@ func init() {
 func initialize() {
 	root = NewRootLogger(LevelWarn)
 	manager = NewManager(root)
-	Closer = NewHandlerCloser()
+	manager.isDefault = true
+	Closer = manager.GetCloser()
+}
+
+// Return the default manager, which the package-level functions work on.
+func GetDefaultManager() *Manager {
+	return manager
 }
 
 // Ensure all log messages are flushed before program exits.
@@ -67,14 +97,53 @@ func SetLoggerMaker(maker LoggerMaker) {
 	manager.SetLoggerMaker(maker)
 }
 
+// Register the context extractor with the specified name for
+// default manager.
+func RegisterContextExtractor(name string, extractor ContextExtractor) {
+	manager.RegisterContextExtractor(name, extractor)
+}
+
+// Remove the context extractor with the specified name from default manager.
+func RemoveContextExtractor(name string) {
+	manager.RemoveContextExtractor(name)
+}
+
+// Set the fatal policy for default manager.
+func SetFatalPolicy(policy FatalPolicy) {
+	manager.SetFatalPolicy(policy)
+}
+
+// Set the exit code used by FatalExit policy for default manager.
+func SetExitCode(code int) {
+	manager.SetExitCode(code)
+}
+
+// Add a hook to run before exiting or panicking on a fatal message
+// for default manager.
+func AddExitHook(hook func()) {
+	manager.AddExitHook(hook)
+}
+
+// Add the specified logger rule to default manager.
+func AddRule(rule *LoggerRule) error {
+	return manager.AddRule(rule)
+}
+
+// Add the level rules in spec, such as "db.*=DEBUG,http.**=WARN",
+// to default manager.
+func AddLevelRules(spec string) error {
+	return manager.AddLevelRules(spec)
+}
+
+// Take a snapshot of the logger hierarchy of default manager.
+func Snapshot() *LoggerSnapshot {
+	return manager.Snapshot()
+}
+
 // Return a logger with the specified name, creating it if necessary.
 // If empty name is specified, return the root logger.
 func GetLogger(name string) Logger {
-	if len(name) > 0 {
-		return manager.GetLogger(name)
-	} else {
-		return root
-	}
+	return manager.GetLogger(name)
 }
 
 // Log a message with severity "LevelFatal" on the root logger.
@@ -1,14 +1,21 @@
 package logging
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
 	ErrorNoSuchLevel = errors.New("no such level")
+	ErrorNoManager   = errors.New("no manager")
 )
 
 const (
@@ -81,16 +88,29 @@ type Logger interface {
 	GetPropagate() bool
 	// Set the propagate.
 	SetPropagate(v bool)
-	// Return the logging level attached to this Logger.
+	// Return the logging level attached to this Logger, regardless of
+	// the level overrides of it.
 	GetLevel() LogLevelType
 	// Set the logging level attached to this Logger.
+	// It doesn't affect the level overrides of this Logger, and takes effect
+	// once they are all expired or cancelled.
 	SetLevel(level LogLevelType) error
+	// Override the logging level of this Logger for the specified duration.
+	// See Manager.PushLevelOverride() for details.
+	SetLevelFor(
+		level LogLevelType, duration time.Duration) (*LevelOverride, error)
 	// Query whether this Logger is enabled for specified logging level.
 	IsEnabledFor(level LogLevelType) bool
 	// Get the effective level for this Logger.
 	// An effective level is the first level value of Logger and its all parent
 	// in the Logger hierarchy, which is not equal to LevelNotset.
 	GetEffectiveLevel() LogLevelType
+	// Return the stack level of Logger.
+	GetStackLevel() LogLevelType
+	// Set the stack level of Logger. The stack of logging call is captured
+	// into the record whose level is at or above the stack level.
+	// LevelNotset disables stack capturing.
+	SetStackLevel(level LogLevelType) error
 
 	// Fatal formats using the default formats for its operands and
 	// logs a message with severity "LevelFatal".
@@ -136,6 +156,27 @@ type Logger interface {
 	// logs a message with specified severity level.
 	Logf(level LogLevelType, format string, args ...interface{})
 
+	// FatalErr formats according to a format specifier and
+	// logs a message with severity "LevelFatal" and the specified error.
+	FatalErr(err error, format string, args ...interface{})
+	// ErrorErr formats according to a format specifier and
+	// logs a message with severity "LevelError" and the specified error.
+	ErrorErr(err error, format string, args ...interface{})
+	// WarnErr formats according to a format specifier and
+	// logs a message with severity "LevelWarn" and the specified error.
+	WarnErr(err error, format string, args ...interface{})
+	// LogErr formats according to a format specifier and
+	// logs a message with specified severity level and the specified error.
+	LogErr(level LogLevelType, err error, format string, args ...interface{})
+
+	// Return a Logger which stamps the specified fields, given as
+	// alternating keys and values, on every record it logs.
+	With(keyValues ...interface{}) Logger
+	// Return a Logger which attaches the specified context to every record
+	// it logs, with fields pulled out of the context by the context
+	// extractors registered in Manager.
+	Ctx(ctx context.Context) Logger
+
 	// Add the specified handler to this Logger.
 	AddHandler(handler Handler)
 	// Remove the specified handler from this Logger.
@@ -144,6 +185,15 @@ type Logger interface {
 	GetHandlers() []Handler
 	// Call all handlers on the specified record.
 	CallHandlers(record *LogRecord)
+	// Call the filters of this Logger on the specified record, then call
+	// the handlers of this Logger and its parents if it passes.
+	Handle(record *LogRecord)
+	// Run the specified function in a new go routine, in which any panic
+	// is recovered and logged by this Logger.
+	GoSafe(f func())
+	// Return a writer which logs every line written to it on this Logger
+	// in the specified level.
+	Writer(level LogLevelType) io.WriteCloser
 
 	// Filterer
 	Filterer
@@ -183,11 +233,51 @@ func findCaller() *CallerInfo {
 	}
 }
 
+// The cached effective level of a logger is packed in an uint64, with the
+// level in the lowest 8 bits, a valid flag in the next bit and the level
+// epoch when it's cached in the rest bits. The level epoch is increased on
+// every change of the level or the parent of any logger, so that all cached
+// levels are invalidated at once, and a stale level computed before the
+// change would never be valid after it.
+const (
+	levelCacheLevel      uint64 = 1<<8 - 1
+	levelCacheValid      uint64 = 1 << 8
+	levelCacheEpochShift        = 9
+)
+
+// The level epoch, shared by all managers.
+var levelEpoch uint64
+
+// Invalidate the cached effective levels of all loggers.
+func resetLevelCaches() {
+	atomic.AddUint64(&levelEpoch, 1)
+}
+
+// Loggers which cache their effective levels, as StandardLogger and all
+// loggers embedding it do.
+type levelCacher interface {
+	effectiveLevelCache() *uint64
+}
+
+// Return the effective level cache of the node, or nil if it has none.
+func levelCacheOf(node Node) *uint64 {
+	if cacher, ok := node.(levelCacher); ok {
+		return cacher.effectiveLevelCache()
+	}
+	return nil
+}
+
 // The standard logger implementation class.
 type StandardLogger struct {
+	// It's accessed atomically and kept as the first field for 64-bit
+	// alignment.
+	effectiveLevel uint64
 	*StandardFilterer
 	name           string
 	level          LogLevelType
+	override       LogLevelType
+	overridden     bool
+	stackLevel     LogLevelType
 	findCallerFunc FindCallerFunc
 	parent         Logger
 	propagate      bool
@@ -204,6 +294,7 @@ func NewStandardLogger(name string, level LogLevelType) *StandardLogger {
 		name:             name,
 		findCallerFunc:   findCaller,
 		level:            level,
+		stackLevel:       LevelNotset,
 		propagate:        true,
 		handlers:         NewListSet(),
 		manager:          nil,
@@ -245,14 +336,63 @@ func (self *StandardLogger) GetLevel() LogLevelType {
 	return self.level
 }
 
+// Return the level of the latest level override of this logger, and
+// whether there is any.
+func (self *StandardLogger) GetLevelOverride() (LogLevelType, bool) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.override, self.overridden
+}
+
+// Set the level of this logger. The level epoch shared by all managers is
+// increased, which invalidates the cached effective levels of all loggers
+// in every manager, not only the ones of this logger and its descendants.
 func (self *StandardLogger) SetLevel(level LogLevelType) error {
 	_, ok := getLevelName(level)
 	if !ok {
 		return ErrorNoSuchLevel
 	}
 	self.lock.Lock()
-	defer self.lock.Unlock()
 	self.level = level
+	self.lock.Unlock()
+	resetLevelCaches()
+	return nil
+}
+
+func (self *StandardLogger) SetLevelFor(
+	level LogLevelType, duration time.Duration) (*LevelOverride, error) {
+
+	manager := self.GetManager()
+	if manager == nil {
+		return nil, ErrorNoManager
+	}
+	return manager.PushLevelOverride(self, level, duration)
+}
+
+// Set the level override of this logger, which is maintained by manager.
+// The cached effective levels of all loggers are invalidated as SetLevel()
+// does.
+func (self *StandardLogger) setLevelOverride(level LogLevelType, ok bool) {
+	self.lock.Lock()
+	self.override, self.overridden = level, ok
+	self.lock.Unlock()
+	resetLevelCaches()
+}
+
+func (self *StandardLogger) GetStackLevel() LogLevelType {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.stackLevel
+}
+
+func (self *StandardLogger) SetStackLevel(level LogLevelType) error {
+	_, ok := getLevelName(level)
+	if !ok {
+		return ErrorNoSuchLevel
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.stackLevel = level
 	return nil
 }
 
@@ -261,14 +401,39 @@ func (self *StandardLogger) IsEnabledFor(level LogLevelType) bool {
 }
 
 // Get the effective level for this logger.
+// The effective level is cached until the level or the parent of any logger
+// changes, so that it costs only two atomic loads in most cases: one of
+// the shared level epoch and one of the cache of this logger. The epoch
+// can't be packed into the cache of every logger with a single load, since
+// then a change would have to invalidate the caches one by one, racing with
+// the loggers caching their levels concurrently. The extra load is cheap,
+// since the epoch changes rarely and stays in the cache of every CPU.
+func (self *StandardLogger) GetEffectiveLevel() LogLevelType {
+	epoch := atomic.LoadUint64(&levelEpoch) << levelCacheEpochShift
+	cache := atomic.LoadUint64(&self.effectiveLevel)
+	if (cache &^ levelCacheLevel) == (epoch | levelCacheValid) {
+		return LogLevelType(cache & levelCacheLevel)
+	}
+	// The epoch is loaded before the level is computed, so the level is
+	// never valid if the cache is reset in the meantime.
+	level := self.findEffectiveLevel()
+	atomic.StoreUint64(
+		&self.effectiveLevel, epoch|levelCacheValid|uint64(level))
+	return level
+}
+
+func (self *StandardLogger) effectiveLevelCache() *uint64 {
+	return &self.effectiveLevel
+}
+
 // Loop through this logger and its parents in the logger hierarchy,
 // looking for a non-zero logging level. Return the first one found.
-func (self *StandardLogger) GetEffectiveLevel() LogLevelType {
+func (self *StandardLogger) findEffectiveLevel() LogLevelType {
 	self.lock.RLock()
 	defer self.lock.RUnlock()
 	var logger Logger = self
 	for logger != nil {
-		level := logger.GetLevel()
+		level := GetOverriddenLevel(logger)
 		if level != LevelNotset {
 			return level
 		}
@@ -281,6 +446,7 @@ func (self *StandardLogger) Fatal(args ...interface{}) {
 	if self.IsEnabledFor(LevelFatal) {
 		self.doLog(LevelFatal, args...)
 	}
+	self.handleFatal(fmt.Sprint(args...))
 }
 
 func (self *StandardLogger) Error(args ...interface{}) {
@@ -325,6 +491,7 @@ func (self *StandardLogger) Fatalf(format string, args ...interface{}) {
 	if self.IsEnabledFor(LevelFatal) {
 		self.doLogf(LevelFatal, format, args...)
 	}
+	self.handleFatal(fmt.Sprintf(format, args...))
 }
 
 func (self *StandardLogger) Errorf(format string, args ...interface{}) {
@@ -365,20 +532,51 @@ func (self *StandardLogger) Logf(
 	}
 }
 
+func (self *StandardLogger) FatalErr(
+	err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(LevelFatal) {
+		self.doLogErr(LevelFatal, err, format, args...)
+	}
+	self.handleFatal(fmt.Sprintf(format, args...))
+}
+
+func (self *StandardLogger) ErrorErr(
+	err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(LevelError) {
+		self.doLogErr(LevelError, err, format, args...)
+	}
+}
+
+func (self *StandardLogger) WarnErr(
+	err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(LevelWarn) {
+		self.doLogErr(LevelWarn, err, format, args...)
+	}
+}
+
+func (self *StandardLogger) LogErr(
+	level LogLevelType, err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(level) {
+		self.doLogErr(level, err, format, args...)
+	}
+}
+
+// Apply the fatal policy of the manager after a fatal message is logged.
+func (self *StandardLogger) handleFatal(message string) {
+	if manager := self.GetManager(); manager != nil {
+		manager.HandleFatal(message)
+	}
+}
+
 func (self *StandardLogger) doLog(
 	level LogLevelType, args ...interface{}) {
 
 	callerInfo := self.findCallerFunc()
-	record := NewLogRecord(
-		self.name,
-		level,
-		callerInfo.PathName,
-		callerInfo.FileName,
-		callerInfo.LineNo,
-		callerInfo.FuncName,
-		"",
-		false,
-		args)
+	record := self.makeRecord(level, callerInfo, "", false, args)
 	self.Handle(record)
 }
 
@@ -386,6 +584,30 @@ func (self *StandardLogger) doLogf(
 	level LogLevelType, format string, args ...interface{}) {
 
 	callerInfo := self.findCallerFunc()
+	record := self.makeRecord(level, callerInfo, format, true, args)
+	self.Handle(record)
+}
+
+func (self *StandardLogger) doLogErr(
+	level LogLevelType, err error, format string, args ...interface{}) {
+
+	callerInfo := self.findCallerFunc()
+	record := self.makeRecord(level, callerInfo, format, true, args)
+	record.Err = err
+	self.Handle(record)
+}
+
+// Make a record for a logging call. The stack of the logging call is
+// captured into the record if the level is at or above the stack level
+// of this logger. It should be called by doLog() and its siblings only,
+// so that the right number of stack frames are skipped.
+func (self *StandardLogger) makeRecord(
+	level LogLevelType,
+	callerInfo *CallerInfo,
+	format string,
+	useFormat bool,
+	args []interface{}) *LogRecord {
+
 	record := NewLogRecord(
 		self.name,
 		level,
@@ -394,9 +616,29 @@ func (self *StandardLogger) doLogf(
 		callerInfo.LineNo,
 		callerInfo.FuncName,
 		format,
-		true,
+		useFormat,
 		args)
-	self.Handle(record)
+	stackLevel := self.GetStackLevel()
+	if (stackLevel != LevelNotset) && (level >= stackLevel) {
+		// skip makeRecord(), doLog() and the logging method
+		record.Stack = CaptureStack(3)
+	}
+	return record
+}
+
+// Return a derived logger which stamps the specified fields on every record
+// it creates. The derived logger shares everything else, such as level,
+// handlers and filters, with this logger.
+func (self *StandardLogger) With(keyValues ...interface{}) Logger {
+	return NewDerivedLogger(self, NewFields(keyValues...))
+}
+
+// Return a derived logger which attaches the specified context on every
+// record it creates.
+func (self *StandardLogger) Ctx(ctx context.Context) Logger {
+	object := NewDerivedLogger(self, nil)
+	object.ctx = ctx
+	return object
 }
 
 // The informations of caller of this module.
@@ -422,6 +664,17 @@ func (self *StandardLogger) Handle(record *LogRecord) {
 	}
 }
 
+func (self *StandardLogger) GoSafe(f func()) {
+	go func() {
+		defer Recover(self, nil)
+		f()
+	}()
+}
+
+func (self *StandardLogger) Writer(level LogLevelType) io.WriteCloser {
+	return NewLogWriter(self, level)
+}
+
 // Pass a record to all relevant handlers.
 // Loop through all handlers for this logger and its parents in the logger
 // hierarchy. Stop searching up the hierarchy whenever a logger with the
@@ -439,12 +692,19 @@ func (self *StandardLogger) traverseHandlers(record *LogRecord) {
 	}
 }
 
+// Add the specified handler to this logger. The handler is adopted by
+// the manager of this logger, if it's not the default manager, so that
+// it's closed by the manager rather than the package-level Closer.
 func (self *StandardLogger) AddHandler(handler Handler) {
 	self.lock.Lock()
-	defer self.lock.Unlock()
 	if !self.handlers.SetContains(handler) {
 		self.handlers.SetAdd(handler)
 	}
+	manager := self.manager
+	self.lock.Unlock()
+	if manager != nil {
+		manager.AdoptHandler(handler)
+	}
 }
 
 func (self *StandardLogger) RemoveHandler(handler Handler) {
@@ -495,10 +755,13 @@ func (self *StandardLogger) GetParent() Logger {
 	return self.parent
 }
 
+// Set the parent of this logger. The cached effective levels of all loggers
+// are reset.
 func (self *StandardLogger) SetParent(parent Logger) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 	self.parent = parent
+	resetLevelCaches()
 }
 
 // Get a logger which is descendant to this one.
@@ -509,10 +772,237 @@ func (self *StandardLogger) SetParent(parent Logger) {
 // It's useful, for example, when the parent logger is named using
 // some string unknown or random.
 func (self *StandardLogger) GetChild(suffix string) Logger {
-	self.lock.RLock()
-	defer self.lock.Unlock()
-	fullname := strings.Join([]string{self.name, suffix}, ".")
-	return self.manager.GetLogger(fullname)
+	fullname := strings.Join([]string{self.GetName(), suffix}, ".")
+	return self.GetManager().GetLogger(fullname)
+}
+
+// A lightweight logger derived from a standard logger, which stamps its
+// fields and context on every record it creates. It's usually created by Logger.With()
+// rather than instantiated directly, and it's not registered in the manager.
+// All methods other than the logging ones are delegated to the original
+// logger, so any change of level, handlers or filters made on either
+// of them applies to both.
+type DerivedLogger struct {
+	*StandardLogger
+	fields Fields
+	ctx    context.Context
+}
+
+// Initialize a derived logger with the original logger and its fields.
+func NewDerivedLogger(logger *StandardLogger, fields Fields) *DerivedLogger {
+	return &DerivedLogger{
+		StandardLogger: logger,
+		fields:         fields,
+	}
+}
+
+// Return the fields stamped by this logger.
+func (self *DerivedLogger) GetFields() Fields {
+	return self.fields
+}
+
+// Return a derived logger with the specified fields appended to the fields
+// of this logger.
+func (self *DerivedLogger) With(keyValues ...interface{}) Logger {
+	object := NewDerivedLogger(
+		self.StandardLogger, self.fields.Merge(NewFields(keyValues...)))
+	object.ctx = self.ctx
+	return object
+}
+
+// Return the context attached by this logger.
+func (self *DerivedLogger) GetContext() context.Context {
+	return self.ctx
+}
+
+// Return a derived logger with the same fields as this logger,
+// but with the specified context.
+func (self *DerivedLogger) Ctx(ctx context.Context) Logger {
+	object := NewDerivedLogger(self.StandardLogger, self.fields)
+	object.ctx = ctx
+	return object
+}
+
+// Stamp the fields and context of this logger on the specified record.
+// Fields extracted from the context come after the fields of this logger,
+// and the fields already in the record come last.
+func (self *DerivedLogger) stamp(record *LogRecord) {
+	fields := self.fields
+	if self.ctx != nil {
+		record.Context = self.ctx
+		if manager := self.GetManager(); manager != nil {
+			extracted := manager.ExtractContext(self.ctx)
+			if len(extracted) > 0 {
+				fields = fields.Merge(extracted)
+			}
+		}
+	}
+	if len(record.Fields) > 0 {
+		fields = fields.Merge(record.Fields)
+	}
+	record.Fields = fields
+}
+
+// Stamp the fields and context of this logger on the specified record,
+// and then handle it as the original logger does.
+func (self *DerivedLogger) Handle(record *LogRecord) {
+	self.stamp(record)
+	self.StandardLogger.Handle(record)
+}
+
+func (self *DerivedLogger) GoSafe(f func()) {
+	go func() {
+		defer Recover(self, nil)
+		f()
+	}()
+}
+
+func (self *DerivedLogger) Writer(level LogLevelType) io.WriteCloser {
+	return NewLogWriter(self, level)
+}
+
+func (self *DerivedLogger) Fatal(args ...interface{}) {
+	if self.IsEnabledFor(LevelFatal) {
+		self.doLog(LevelFatal, args...)
+	}
+	self.handleFatal(fmt.Sprint(args...))
+}
+
+func (self *DerivedLogger) Error(args ...interface{}) {
+	if self.IsEnabledFor(LevelError) {
+		self.doLog(LevelError, args...)
+	}
+}
+
+func (self *DerivedLogger) Warn(args ...interface{}) {
+	if self.IsEnabledFor(LevelWarn) {
+		self.doLog(LevelWarn, args...)
+	}
+}
+
+func (self *DerivedLogger) Info(args ...interface{}) {
+	if self.IsEnabledFor(LevelInfo) {
+		self.doLog(LevelInfo, args...)
+	}
+}
+
+func (self *DerivedLogger) Debug(args ...interface{}) {
+	if self.IsEnabledFor(LevelDebug) {
+		self.doLog(LevelDebug, args...)
+	}
+}
+
+func (self *DerivedLogger) Trace(args ...interface{}) {
+	if self.IsEnabledFor(LevelTrace) {
+		self.doLog(LevelTrace, args...)
+	}
+}
+
+func (self *DerivedLogger) Log(level LogLevelType, args ...interface{}) {
+	if self.IsEnabledFor(level) {
+		self.doLog(level, args...)
+	}
+}
+
+func (self *DerivedLogger) Fatalf(format string, args ...interface{}) {
+	if self.IsEnabledFor(LevelFatal) {
+		self.doLogf(LevelFatal, format, args...)
+	}
+	self.handleFatal(fmt.Sprintf(format, args...))
+}
+
+func (self *DerivedLogger) Errorf(format string, args ...interface{}) {
+	if self.IsEnabledFor(LevelError) {
+		self.doLogf(LevelError, format, args...)
+	}
+}
+
+func (self *DerivedLogger) Warnf(format string, args ...interface{}) {
+	if self.IsEnabledFor(LevelWarn) {
+		self.doLogf(LevelWarn, format, args...)
+	}
+}
+
+func (self *DerivedLogger) Infof(format string, args ...interface{}) {
+	if self.IsEnabledFor(LevelInfo) {
+		self.doLogf(LevelInfo, format, args...)
+	}
+}
+
+func (self *DerivedLogger) Debugf(format string, args ...interface{}) {
+	if self.IsEnabledFor(LevelDebug) {
+		self.doLogf(LevelDebug, format, args...)
+	}
+}
+
+func (self *DerivedLogger) Tracef(format string, args ...interface{}) {
+	if self.IsEnabledFor(LevelTrace) {
+		self.doLogf(LevelTrace, format, args...)
+	}
+}
+
+func (self *DerivedLogger) Logf(
+	level LogLevelType, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(level) {
+		self.doLogf(level, format, args...)
+	}
+}
+
+func (self *DerivedLogger) FatalErr(
+	err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(LevelFatal) {
+		self.doLogErr(LevelFatal, err, format, args...)
+	}
+	self.handleFatal(fmt.Sprintf(format, args...))
+}
+
+func (self *DerivedLogger) ErrorErr(
+	err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(LevelError) {
+		self.doLogErr(LevelError, err, format, args...)
+	}
+}
+
+func (self *DerivedLogger) WarnErr(
+	err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(LevelWarn) {
+		self.doLogErr(LevelWarn, err, format, args...)
+	}
+}
+
+func (self *DerivedLogger) LogErr(
+	level LogLevelType, err error, format string, args ...interface{}) {
+
+	if self.IsEnabledFor(level) {
+		self.doLogErr(level, err, format, args...)
+	}
+}
+
+func (self *DerivedLogger) doLog(level LogLevelType, args ...interface{}) {
+	callerInfo := self.findCallerFunc()
+	record := self.makeRecord(level, callerInfo, "", false, args)
+	self.Handle(record)
+}
+
+func (self *DerivedLogger) doLogf(
+	level LogLevelType, format string, args ...interface{}) {
+
+	callerInfo := self.findCallerFunc()
+	record := self.makeRecord(level, callerInfo, format, true, args)
+	self.Handle(record)
+}
+
+func (self *DerivedLogger) doLogErr(
+	level LogLevelType, err error, format string, args ...interface{}) {
+
+	callerInfo := self.findCallerFunc()
+	record := self.makeRecord(level, callerInfo, format, true, args)
+	record.Err = err
+	self.Handle(record)
 }
 
 // A root logger is not that different to any other logger, except that
@@ -540,21 +1030,76 @@ func defaultLoggerMaker(name string) Logger {
 }
 
 // This is [under normal circumstances] just one manager instance, which
-// holds the hierarchy of loggers.
+// holds the hierarchy of loggers. Every manager is self-contained, with its
+// own root logger, handler closer and configuration entry point, so that
+// there could be multiple isolated logger hierarchies in one process.
 type Manager struct {
 	root        Logger
+	closer      *HandlerCloser
+	isDefault   bool
 	loggers     map[string]Node
 	loggerMaker LoggerMaker
 	lock        sync.Mutex
+	extractors  []namedContextExtractor
+	extractLock sync.RWMutex
+	fatalPolicy FatalPolicy
+	exitCode    int
+	exitHooks   []func()
+	exitFunc    func(code int)
+	fatalLock   sync.RWMutex
+	rules       []*LoggerRule
+	ruleLock    sync.RWMutex
+	overrides   []*LevelOverride
+	overrideID  uint64
+	overLock    sync.Mutex
+	applied     *appliedConfig
+	configFile  string
+	configLoad  func(file string) (*Conf, error)
+	configLock  sync.Mutex
 }
 
 // Initialize the manager with the root node of the logger hierarchy.
+// A new handler closer is created for the manager.
 func NewManager(logger Logger) *Manager {
-	return &Manager{
+	object := &Manager{
 		root:        logger,
+		closer:      NewHandlerCloser(),
 		loggers:     make(map[string]Node),
 		loggerMaker: defaultLoggerMaker,
+		fatalPolicy: FatalContinue,
+		exitCode:    1,
+		exitFunc:    os.Exit,
 	}
+	logger.SetManager(object)
+	return object
+}
+
+// Return the root logger of this manager.
+func (self *Manager) GetRoot() Logger {
+	return self.root
+}
+
+// Return the handler closer of this manager.
+func (self *Manager) GetCloser() *HandlerCloser {
+	return self.closer
+}
+
+// Register the specified handler to the closer of this manager, rather
+// than the package-level Closer which handlers register to on creation.
+// It's called for every handler added to the loggers of this manager, and
+// does nothing for the default manager, whose closer is the package-level
+// Closer, or was before Shutdown().
+func (self *Manager) AdoptHandler(handler Handler) {
+	if !self.isDefault {
+		Closer.RemoveHandler(handler)
+		self.closer.AddHandler(handler)
+	}
+}
+
+// Ensure all log messages are flushed by closing all handlers known to
+// the closer of this manager.
+func (self *Manager) Shutdown() {
+	self.closer.Close()
 }
 
 // Set the logger maker to be used when instantiating
@@ -573,10 +1118,24 @@ func (self *Manager) SetLoggerMaker(maker LoggerMaker) {
 // exist but a child of it did], replace it with the created logger and fix up
 // the parent/child references which pointed to the placeholder to now point
 // to the logger.
+//
+// If empty name is specified, return the root logger.
 func (self *Manager) GetLogger(name string) Logger {
+	if len(name) == 0 {
+		return self.root
+	}
+	logger, created := self.getLogger(name)
+	if created {
+		self.applyRules(logger)
+	}
+	return logger
+}
+
+// Get a logger with the specified name, creating it if it doesn't yet
+// exists. Return true as created if the logger is created.
+func (self *Manager) getLogger(name string) (logger Logger, created bool) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
-	var logger Logger
 	node, ok := self.loggers[name]
 	if ok {
 		switch node.Type() {
@@ -587,8 +1146,9 @@ func (self *Manager) GetLogger(name string) Logger {
 			self.loggers[name] = logger
 			self.fixupChildren(placeHolder, logger)
 			self.fixupParents(logger)
+			created = true
 		case NodeLogger:
-			logger, _ = node.(*StandardLogger)
+			logger, _ = node.(Logger)
 		default:
 			panic("invalid node type")
 		}
@@ -597,8 +1157,9 @@ func (self *Manager) GetLogger(name string) Logger {
 		logger.SetManager(self)
 		self.loggers[name] = logger
 		self.fixupParents(logger)
+		created = true
 	}
-	return logger
+	return logger, created
 }
 
 // Ensure that there are either loggers or placeholders all the way from
@@ -618,7 +1179,7 @@ func (self *Manager) fixupParents(logger Logger) {
 				placeHolder, _ := node.(*PlaceHolder)
 				placeHolder.Append(logger)
 			case NodeLogger:
-				parent = logger
+				parent, _ = node.(Logger)
 			default:
 				panic("invalid node type")
 			}
@@ -626,7 +1187,7 @@ func (self *Manager) fixupParents(logger Logger) {
 		index = strings.LastIndex(parentStr, ".")
 	}
 	if parent == nil {
-		parent = root
+		parent = self.root
 	}
 	logger.SetParent(parent)
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/hhkbp2/testify/require"
+	"strings"
 	"testing"
 	"time"
 )
@@ -75,3 +76,168 @@ func TestLoggerLogToHandler(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, testError.Error(), record.GetMessage())
 }
+
+func TestLoggerWith(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("with")
+	logger.SetLevel(LevelDebug)
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+
+	derived := logger.With("request", 1, "user", "abc")
+	derived.Infof("msg: %s", "abcd")
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "msg: abcd", record.GetMessage())
+	require.Equal(t, NewFields("request", 1, "user", "abc"), record.Fields)
+
+	// fields are accumulated without touching the original loggers
+	derived.With("user", "def").Info("message")
+	record, err = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, 3, len(record.Fields))
+	user, ok := record.Fields.Get("user")
+	require.True(t, ok)
+	require.Equal(t, "def", user)
+	logger.Info("message")
+	record, err = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(record.Fields))
+
+	// the derived logger shares level with the original one
+	logger.SetLevel(LevelError)
+	derived.Info("message")
+	_, err = handler.GetEmitOnTimeout(time.Millisecond * 10)
+	require.Equal(t, ErrorTimeout, err)
+}
+
+func TestLoggerErrorErr(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("err")
+	logger.SetLevel(LevelDebug)
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+
+	inner := errors.New("inner")
+	err := fmt.Errorf("outer: %w", inner)
+	logger.ErrorErr(err, "failed: %d", 1)
+	record, e := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, e)
+	require.Equal(t, "failed: 1", record.GetMessage())
+	require.Equal(t, err, record.Err)
+	require.Equal(t, []error{err, inner}, record.GetErrorChain())
+	require.Equal(t, "", record.Stack)
+
+	// the stack is captured at or above the stack level only
+	require.Nil(t, logger.SetStackLevel(LevelError))
+	logger.Warn("message")
+	record, e = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, e)
+	require.Equal(t, "", record.Stack)
+	logger.With("a", 1).ErrorErr(err, "message")
+	record, e = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, e)
+	require.True(t, strings.HasPrefix(record.Stack,
+		"github.com/hhkbp2/go-logging.TestLoggerErrorErr\n"))
+
+	formatter := NewStandardFormatter("%(message)s: %(error)s", "")
+	require.Equal(t, "message: outer: inner\n", formatter.Format(record))
+}
+
+func TestManagerIsolation(t *testing.T) {
+	defer Shutdown()
+	root2 := NewRootLogger(LevelInfo)
+	manager2 := NewManager(root2)
+	require.Equal(t, root2, manager2.GetLogger(""))
+	require.Equal(t, manager2, root2.GetManager())
+
+	logger1 := GetLogger("iso.a")
+	logger2 := manager2.GetLogger("iso.a")
+	require.False(t, logger1 == logger2)
+	require.Equal(t, root, logger1.GetParent())
+	require.Equal(t, root2, logger2.GetParent())
+	require.Equal(t, LevelWarn, logger1.GetEffectiveLevel())
+	require.Equal(t, LevelInfo, logger2.GetEffectiveLevel())
+
+	parent := manager2.GetLogger("iso")
+	require.Equal(t, parent, logger2.GetParent())
+	require.Equal(t, root2, parent.GetParent())
+	child := manager2.GetLogger("iso.a.b")
+	require.Equal(t, logger2, child.GetParent())
+	require.Equal(t, LevelInfo, child.GetEffectiveLevel())
+
+	// handlers created by DictConfig go to the closer of manager2
+	conf := &Conf{
+		Handlers: map[string]ConfMap{
+			"h": {"class": "NullHandler"},
+		},
+		Root: ConfMap{
+			"level":    "DEBUG",
+			"handlers": []interface{}{"h"},
+		},
+	}
+	require.Nil(t, manager2.DictConfig(conf))
+	require.Equal(t, LevelDebug, root2.GetLevel())
+	require.Equal(t, LevelWarn, root.GetLevel())
+	handler := root2.GetHandlers()[0]
+	require.True(t, manager2.GetCloser().handlers.SetContains(handler))
+	require.False(t, Closer.handlers.SetContains(handler))
+
+	// so do handlers added to the loggers of manager2 by hand
+	handler2 := NewNullHandler()
+	require.True(t, Closer.handlers.SetContains(handler2))
+	logger2.AddHandler(handler2)
+	require.True(t, manager2.GetCloser().handlers.SetContains(handler2))
+	require.False(t, Closer.handlers.SetContains(handler2))
+	// while the ones of default manager stay in Closer
+	handler1 := NewNullHandler()
+	logger1.AddHandler(handler1)
+	require.True(t, Closer.handlers.SetContains(handler1))
+	manager2.Shutdown()
+}
+
+func TestLoggerEffectiveLevelCache(t *testing.T) {
+	root := NewRootLogger(LevelWarn)
+	manager := NewManager(root)
+	defer manager.Shutdown()
+	child := manager.GetLogger("cache.a.b")
+	other := manager.GetLogger("cachex")
+	require.Equal(t, LevelWarn, child.GetEffectiveLevel())
+	require.Equal(t, LevelWarn, other.GetEffectiveLevel())
+	require.False(t, child.IsEnabledFor(LevelInfo))
+
+	// root level change is visible to all loggers
+	require.Nil(t, root.SetLevel(LevelError))
+	require.Equal(t, LevelError, child.GetEffectiveLevel())
+	require.Equal(t, LevelError, other.GetEffectiveLevel())
+
+	// inserting a logger between the root and child resets the child
+	parent := manager.GetLogger("cache.a")
+	require.Equal(t, parent, child.GetParent())
+	require.Equal(t, LevelError, child.GetEffectiveLevel())
+	require.Nil(t, parent.SetLevel(LevelDebug))
+	require.Equal(t, LevelDebug, child.GetEffectiveLevel())
+	require.True(t, child.IsEnabledFor(LevelInfo))
+	require.Equal(t, LevelError, other.GetEffectiveLevel())
+
+	// a level set by loggerMaker is visible to the re-parented children
+	manager.SetLoggerMaker(func(name string) Logger {
+		return NewStandardLogger(name, LevelInfo)
+	})
+	require.Nil(t, parent.SetLevel(LevelNotset))
+	require.Equal(t, LevelError, child.GetEffectiveLevel())
+	manager.GetLogger("cache")
+	require.Equal(t, LevelInfo, child.GetEffectiveLevel())
+	require.Equal(t, LevelInfo, parent.GetEffectiveLevel())
+}
+
+func BenchmarkLoggerDisabled(b *testing.B) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	logger := manager.GetLogger("bench.a.b.c.d")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Debug("message")
+	}
+}
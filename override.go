@@ -0,0 +1,179 @@
+package logging
+
+import (
+	"errors"
+	"time"
+)
+
+// A temporary level override of a logger, which is pushed onto the override
+// stack of manager and popped when it expires or is cancelled.
+type LevelOverride struct {
+	ID      uint64
+	Logger  Logger
+	Level   LogLevelType
+	Expires time.Time
+	manager *Manager
+	timer   *time.Timer
+}
+
+// Cancel this override before it expires.
+// Return false if it has expired or been cancelled already.
+func (self *LevelOverride) Cancel() bool {
+	return self.manager.CancelLevelOverride(self.ID)
+}
+
+// Loggers which support level overrides, as StandardLogger and all loggers
+// embedding it do.
+type levelOverrider interface {
+	levelCacher
+	GetLevelOverride() (LogLevelType, bool)
+	setLevelOverride(level LogLevelType, ok bool)
+}
+
+// Return the level in effect for the specified logger itself, which is
+// the level of its latest level override if there is any, or the level
+// attached to it otherwise. Unlike GetEffectiveLevel(), the ancestors of
+// the logger are not consulted.
+func GetOverriddenLevel(logger Logger) LogLevelType {
+	if overrider, ok := logger.(levelOverrider); ok {
+		if level, ok := overrider.GetLevelOverride(); ok {
+			return level
+		}
+	}
+	return logger.GetLevel()
+}
+
+// Override the level of the specified logger for the specified duration,
+// e.g. to set a subtree to DEBUG for the next 10 minutes.
+//
+// The latest override of a logger takes effect until it expires or is
+// cancelled, then the previous one if there is, and finally the level of
+// the logger itself. Since the level set by SetLevel() or DictConfig() is
+// kept under the overrides rather than being replaced, it's never clobbered
+// when the overrides are gone.
+func (self *Manager) PushLevelOverride(
+	logger Logger,
+	level LogLevelType,
+	duration time.Duration) (*LevelOverride, error) {
+
+	return self.pushLevelOverride(logger, level, duration, nil)
+}
+
+// Push a level override as PushLevelOverride() does, with onExpire called
+// after it expires, but not if it's cancelled.
+func (self *Manager) pushLevelOverride(
+	logger Logger,
+	level LogLevelType,
+	duration time.Duration,
+	onExpire func()) (*LevelOverride, error) {
+
+	if _, ok := getLevelName(level); !ok {
+		return nil, ErrorNoSuchLevel
+	}
+	if duration <= 0 {
+		return nil, errors.New("duration of level override should be positive")
+	}
+	if _, ok := logger.(levelOverrider); !ok {
+		return nil, errors.New("logger doesn't support level override")
+	}
+	self.overLock.Lock()
+	defer self.overLock.Unlock()
+	self.overrideID++
+	override := &LevelOverride{
+		ID:      self.overrideID,
+		Logger:  logger,
+		Level:   level,
+		Expires: time.Now().Add(duration),
+		manager: self,
+	}
+	id := override.ID
+	override.timer = time.AfterFunc(duration, func() {
+		if self.CancelLevelOverride(id) && (onExpire != nil) {
+			onExpire()
+		}
+	})
+	self.overrides = append(self.overrides, override)
+	self.applyLevelOverrides(logger)
+	return override, nil
+}
+
+// Cancel the level override with the specified ID.
+// Return false if there is no such override.
+func (self *Manager) CancelLevelOverride(id uint64) bool {
+	self.overLock.Lock()
+	defer self.overLock.Unlock()
+	for i, override := range self.overrides {
+		if override.ID == id {
+			override.timer.Stop()
+			overrides := make([]*LevelOverride, 0, len(self.overrides)-1)
+			overrides = append(overrides, self.overrides[:i]...)
+			self.overrides = append(overrides, self.overrides[i+1:]...)
+			self.applyLevelOverrides(override.Logger)
+			return true
+		}
+	}
+	return false
+}
+
+// Cancel all level overrides of the specified logger.
+// Return the number of overrides cancelled.
+func (self *Manager) CancelLevelOverrides(logger Logger) int {
+	self.overLock.Lock()
+	defer self.overLock.Unlock()
+	overrides := make([]*LevelOverride, 0, len(self.overrides))
+	for _, override := range self.overrides {
+		if sameLogger(override.Logger, logger) {
+			override.timer.Stop()
+		} else {
+			overrides = append(overrides, override)
+		}
+	}
+	count := len(self.overrides) - len(overrides)
+	self.overrides = overrides
+	if count > 0 {
+		self.applyLevelOverrides(logger)
+	}
+	return count
+}
+
+// Return all level overrides in effect, from the oldest to the latest.
+func (self *Manager) GetLevelOverrides() []*LevelOverride {
+	self.overLock.Lock()
+	defer self.overLock.Unlock()
+	return append([]*LevelOverride(nil), self.overrides...)
+}
+
+// Return the level overrides of the specified logger, from the oldest to
+// the latest.
+func (self *Manager) GetLevelOverridesOf(logger Logger) []*LevelOverride {
+	var result []*LevelOverride
+	for _, override := range self.GetLevelOverrides() {
+		if sameLogger(override.Logger, logger) {
+			result = append(result, override)
+		}
+	}
+	return result
+}
+
+// Apply the latest override of the specified logger to it, or clear its
+// override if there is none. The override lock should be held.
+func (self *Manager) applyLevelOverrides(logger Logger) {
+	overrider, _ := logger.(levelOverrider)
+	for i := len(self.overrides) - 1; i >= 0; i-- {
+		if sameLogger(self.overrides[i].Logger, logger) {
+			overrider.setLevelOverride(self.overrides[i].Level, true)
+			return
+		}
+	}
+	overrider.setLevelOverride(LevelNotset, false)
+}
+
+// Report whether the two loggers are the same one, which is true as well
+// for a logger and another one embedding it, e.g. RootLogger.
+func sameLogger(a, b Logger) bool {
+	cacheA, cacheB := levelCacheOf(a), levelCacheOf(b)
+	if (cacheA != nil) || (cacheB != nil) {
+		return cacheA == cacheB
+	}
+	return a == b
+}
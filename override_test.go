@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestLoggerSetLevelFor(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	logger := manager.GetLogger("over.a")
+	child := manager.GetLogger("over.a.b")
+	require.Nil(t, logger.SetLevel(LevelError))
+
+	override, err := logger.SetLevelFor(LevelDebug, 50*time.Millisecond)
+	require.Nil(t, err)
+	require.Equal(t, LevelError, logger.GetLevel())
+	require.Equal(t, LevelDebug, GetOverriddenLevel(logger))
+	require.Equal(t, LevelDebug, child.GetEffectiveLevel())
+	require.Equal(t, []*LevelOverride{override},
+		manager.GetLevelOverridesOf(logger))
+	snapshot := manager.Snapshot().Children[0].Children[0]
+	require.Equal(t, "over.a", snapshot.Name)
+	require.Equal(t, 1, len(snapshot.Overrides))
+	require.Equal(t, "DEBUG", snapshot.Overrides[0].Level)
+	require.Equal(t, override.ID, snapshot.Overrides[0].ID)
+
+	// the level set in the meantime is kept under the override
+	require.Nil(t, logger.SetLevel(LevelInfo))
+	require.Equal(t, LevelInfo, logger.GetLevel())
+	require.Equal(t, LevelDebug, GetOverriddenLevel(logger))
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, LevelInfo, GetOverriddenLevel(logger))
+	require.Equal(t, LevelInfo, child.GetEffectiveLevel())
+	require.Equal(t, 0, len(manager.GetLevelOverrides()))
+	require.False(t, override.Cancel())
+}
+
+func TestManagerLevelOverrideStack(t *testing.T) {
+	root := NewRootLogger(LevelWarn)
+	manager := NewManager(root)
+	defer manager.Shutdown()
+	logger := manager.GetLogger("stack.a")
+
+	first, err := logger.SetLevelFor(LevelInfo, time.Minute)
+	require.Nil(t, err)
+	second, err := manager.PushLevelOverride(logger, LevelTrace, time.Minute)
+	require.Nil(t, err)
+	third, err := manager.PushLevelOverride(root, LevelError, time.Minute)
+	require.Nil(t, err)
+	require.Equal(t, []*LevelOverride{first, second, third},
+		manager.GetLevelOverrides())
+	require.Equal(t, LevelTrace, GetOverriddenLevel(logger))
+	require.Equal(t, LevelError, GetOverriddenLevel(root))
+	require.Equal(t, LevelWarn, root.GetLevel())
+	require.Equal(t, []*LevelOverride{third},
+		manager.GetLevelOverridesOf(manager.GetRoot()))
+
+	// cancel the latest one, and the previous one takes effect
+	require.True(t, second.Cancel())
+	require.Equal(t, LevelInfo, GetOverriddenLevel(logger))
+	require.True(t, manager.CancelLevelOverride(third.ID))
+	require.Equal(t, LevelWarn, GetOverriddenLevel(root))
+	require.Equal(t, LevelInfo, logger.GetEffectiveLevel())
+	require.Equal(t, 1, manager.CancelLevelOverrides(logger))
+	require.Equal(t, LevelNotset, GetOverriddenLevel(logger))
+	require.Equal(t, LevelWarn, logger.GetEffectiveLevel())
+	require.False(t, first.Cancel())
+
+	_, err = logger.SetLevelFor(LevelInfo, 0)
+	require.NotNil(t, err)
+	_, err = logger.SetLevelFor(LogLevelType(77), time.Minute)
+	require.Equal(t, ErrorNoSuchLevel, err)
+	_, err = NewStandardLogger("x", LevelNotset).SetLevelFor(
+		LevelInfo, time.Minute)
+	require.Equal(t, ErrorNoManager, err)
+}
+
+func TestLevelOverride_SetLevel(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	logger := manager.GetLogger("over.b")
+	require.Nil(t, logger.SetLevel(LevelWarn))
+
+	// writing back the level read doesn't make the override permanent
+	override, err := logger.SetLevelFor(LevelDebug, time.Minute)
+	require.Nil(t, err)
+	require.Nil(t, logger.SetLevel(logger.GetLevel()))
+	require.Equal(t, LevelDebug, logger.GetEffectiveLevel())
+	require.True(t, override.Cancel())
+	require.Equal(t, LevelWarn, logger.GetLevel())
+	require.Equal(t, LevelWarn, logger.GetEffectiveLevel())
+}
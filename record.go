@@ -1,10 +1,96 @@
 package logging
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	// The time when this package is initialized, which is about the start
+	// of the process.
+	processStartTime = time.Now()
+	processID        = os.Getpid()
+
+	processHostname   string
+	processExecutable string
+	processInfoOnce   sync.Once
+
+	// Whether to capture the goroutine id when a record is created, which
+	// is set once a formatter references it.
+	captureGoroutine int32
+)
+
+// A key/value pair of structured information attached to a LogRecord.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// An ordered collection of fields.
+type Fields []Field
+
+// Make fields from alternating keys and values, such as
+//     NewFields("request", id, "user", name)
+// A key which is not a string is converted to one with fmt.Sprint().
+// A trailing key without value gets a nil value.
+func NewFields(keyValues ...interface{}) Fields {
+	fields := make(Fields, 0, (len(keyValues)+1)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		key, ok := keyValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyValues[i])
+		}
+		var value interface{}
+		if i+1 < len(keyValues) {
+			value = keyValues[i+1]
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+	}
+	return fields
+}
+
+// Return a new collection with the specified fields appended to these ones.
+// The receiver is never modified.
+func (self Fields) Merge(fields Fields) Fields {
+	result := make(Fields, 0, len(self)+len(fields))
+	result = append(result, self...)
+	return append(result, fields...)
+}
+
+// Return the value of the last field with the specified key.
+func (self Fields) Get(key string) (value interface{}, ok bool) {
+	for i := len(self) - 1; i >= 0; i-- {
+		if self[i].Key == key {
+			return self[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Return the textual representation of these fields in the form of
+// "key1=value1 key2=value2".
+func (self Fields) String() string {
+	var buf bytes.Buffer
+	for i, field := range self {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(field.Key)
+		buf.WriteString("=")
+		buf.WriteString(fmt.Sprint(field.Value))
+	}
+	return buf.String()
+}
+
 // A LogRecord instance represents an event being logged.
 // LogRecord instances are created every time something is logged. They
 // contain all the information pertinent to the event being logged. The
@@ -13,6 +99,13 @@ import (
 // to create the message field of the record. The record also includes
 // information such as when the record was created, the source line where
 // the logging call was made, and any exception information to be logged.
+// Fields carries the structured key/value pairs stamped by the logger
+// which creates the record, e.g. a logger returned by Logger.With().
+// Context is the context passed to Logger.Ctx(), if any. Err is the error
+// passed to Logger.ErrorErr() and its siblings, and Stack is the stack of
+// the logging call if it's captured. Goroutine is the id of the goroutine
+// which creates the record, which is captured only if any formatter
+// references it, or 0 if it's not captured.
 type LogRecord struct {
 	CreatedTime time.Time
 	AscTime     string
@@ -26,6 +119,11 @@ type LogRecord struct {
 	UseFormat   bool
 	Args        []interface{}
 	Message     string
+	Fields      Fields
+	Context     context.Context
+	Err         error
+	Stack       string
+	Goroutine   uint64
 }
 
 // Initialize a logging record with interesting information.
@@ -40,6 +138,10 @@ func NewLogRecord(
 	useFormat bool,
 	args []interface{}) *LogRecord {
 
+	var goroutine uint64
+	if atomic.LoadInt32(&captureGoroutine) != 0 {
+		goroutine = CurrentGoroutineID()
+	}
 	return &LogRecord{
 		CreatedTime: time.Now(),
 		Name:        name,
@@ -52,6 +154,7 @@ func NewLogRecord(
 		UseFormat:   useFormat,
 		Args:        args,
 		Message:     "",
+		Goroutine:   goroutine,
 	}
 }
 
@@ -73,3 +176,105 @@ func (self *LogRecord) GetMessage() string {
 	}
 	return self.Message
 }
+
+// Return the id of the goroutine which creates this LogRecord. If it's not
+// captured, the id of the current goroutine is taken instead, which is
+// the same one unless the record is handled asynchronously.
+func (self *LogRecord) GetGoroutine() uint64 {
+	if self.Goroutine == 0 {
+		self.Goroutine = CurrentGoroutineID()
+	}
+	return self.Goroutine
+}
+
+// Return the milliseconds elapsed from the start of the process to the
+// creation of this LogRecord.
+func (self *LogRecord) GetRelativeCreated() int64 {
+	return int64(self.CreatedTime.Sub(processStartTime) / time.Millisecond)
+}
+
+// Return the millisecond portion of the creation time of this LogRecord.
+func (self *LogRecord) GetMsecs() int {
+	return self.CreatedTime.Nanosecond() / int(time.Millisecond)
+}
+
+// Return the id of the process.
+func (self *LogRecord) GetProcess() int {
+	return processID
+}
+
+// Return the host name reported by the kernel, or empty if it's unknown.
+func (self *LogRecord) GetHostname() string {
+	processInfoOnce.Do(initProcessInfo)
+	return processHostname
+}
+
+// Return the file name of the executable of the process, without
+// the directory.
+func (self *LogRecord) GetExecutable() string {
+	processInfoOnce.Do(initProcessInfo)
+	return processExecutable
+}
+
+func initProcessInfo() {
+	processHostname, _ = os.Hostname()
+	path, err := os.Executable()
+	if (err != nil) && (len(os.Args) > 0) {
+		path = os.Args[0]
+	}
+	if len(path) > 0 {
+		processExecutable = filepath.Base(path)
+	}
+}
+
+// Return the id of the current goroutine, or 0 if it couldn't be found.
+// It's parsed from the header of the goroutine stack, e.g.
+// "goroutine 18 [running]:", so it's relatively expensive.
+func CurrentGoroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, err := strconv.ParseUint(string(buf), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// Return the error of this LogRecord and all the errors it wraps, which are
+// unwrapped one by one with errors.Unwrap().
+func (self *LogRecord) GetErrorChain() []error {
+	return ErrorChain(self.Err)
+}
+
+// Return the specified error and all the errors in its errors.Unwrap() chain.
+func ErrorChain(err error) []error {
+	var result []error
+	for err != nil {
+		result = append(result, err)
+		err = errors.Unwrap(err)
+	}
+	return result
+}
+
+// Return the stack of current goroutine as text, in which every frame takes
+// two lines: the function name and then the file path with line number.
+// The argument skip is the number of stack frames to skip before recording,
+// with 0 identifying the caller of CaptureStack.
+func CaptureStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var buf bytes.Buffer
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Options for Recover().
+type RecoverOptions struct {
+	// The level of the record for the recovered panic.
+	// LevelError is used if it's LevelNotset.
+	Level LogLevelType
+	// Whether to panic again with the recovered value after the record
+	// is logged and all handlers are flushed.
+	RePanic bool
+}
+
+// Recover a panic and log the recovered value with the full stack of panic
+// on the specified logger. It must be called directly by defer, such as
+//     defer logging.Recover(logger, nil)
+// If opts is nil, the default options are used.
+func Recover(logger Logger, opts *RecoverOptions) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	if opts == nil {
+		opts = &RecoverOptions{}
+	}
+	level := opts.Level
+	if level == LevelNotset {
+		level = LevelError
+	}
+	if logger.IsEnabledFor(level) {
+		// skip Recover() itself
+		stack := CaptureStack(1)
+		callerInfo := findPanicCaller()
+		record := NewLogRecord(
+			logger.GetName(),
+			level,
+			callerInfo.PathName,
+			callerInfo.FileName,
+			callerInfo.LineNo,
+			callerInfo.FuncName,
+			"panic: %v",
+			true,
+			[]interface{}{value})
+		if err, ok := value.(error); ok {
+			record.Err = err
+		}
+		record.Stack = stack
+		logger.Handle(record)
+	}
+	if opts.RePanic {
+		if manager := logger.GetManager(); manager != nil {
+			manager.GetCloser().Flush()
+		} else {
+			Closer.Flush()
+		}
+		panic(value)
+	}
+}
+
+// Find the stack frame where the panic is raised, which is the first frame
+// out of the runtime package after the runtime panic function.
+func findPanicCaller() *CallerInfo {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return &CallerInfo{
+				PathName: frame.File,
+				FileName: filepath.Base(frame.File),
+				LineNo:   uint32(frame.Line),
+				FuncName: frame.Function,
+			}
+		}
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			return UnknownCallerInfo
+		}
+	}
+}
+
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestRecover(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("recover")
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+
+	testError := errors.New("test error")
+	func() {
+		defer Recover(logger.With("a", 1), &RecoverOptions{Level: LevelFatal})
+		panic(testError)
+	}()
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, LevelFatal, record.Level)
+	require.Equal(t, "panic: test error", record.GetMessage())
+	require.Equal(t, testError, record.Err)
+	require.Equal(t, NewFields("a", 1), record.Fields)
+	require.Equal(t, "recover_test.go", record.FileName)
+	require.True(t, strings.Contains(record.Stack, "TestRecover.func1"))
+
+	// re-panic with the same value
+	func() {
+		defer func() {
+			require.Equal(t, "again", recover())
+		}()
+		defer Recover(logger, &RecoverOptions{RePanic: true})
+		panic("again")
+	}()
+	record, err = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, LevelError, record.Level)
+	require.Equal(t, "panic: again", record.GetMessage())
+}
+
+func TestLoggerGoSafe(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("recover")
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+	logger.GoSafe(func() {
+		var m map[string]int
+		m["a"] = 1
+	})
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, LevelError, record.Level)
+	require.True(t, strings.HasPrefix(record.GetMessage(), "panic: "))
+	require.Equal(t, "recover_test.go", record.FileName)
+}
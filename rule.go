@@ -0,0 +1,244 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// A rule which applies to all loggers whose names match its pattern,
+// including the loggers created after the rule is added to manager.
+//
+// A pattern is a dot-separated hierarchical name like the logger name, in
+// which every part could be a shell file name pattern as path.Match()
+// accepts, e.g. "*" matches any single part, and the part "**" matches zero
+// or more parts. For example, "db.*" matches "db.pool" but not "db" or
+// "db.pool.conn", while "http.**" matches "http", "http.server" and
+// "http.server.conn".
+type LoggerRule struct {
+	Pattern string
+	Apply   func(logger Logger) error
+}
+
+// Check whether the pattern is well-formed.
+func ValidateRulePattern(pattern string) error {
+	if len(pattern) == 0 {
+		return errors.New("rule pattern should be non-empty")
+	}
+	for _, part := range strings.Split(pattern, ".") {
+		if len(part) == 0 {
+			return errors.New(fmt.Sprintf(
+				"rule pattern: %s has empty part", pattern))
+		}
+		if _, err := path.Match(part, ""); err != nil {
+			return errors.New(fmt.Sprintf(
+				"rule pattern: %s is malformed", pattern))
+		}
+	}
+	return nil
+}
+
+// Report whether the logger name matches the rule pattern.
+func MatchRulePattern(pattern, name string) bool {
+	return matchParts(strings.Split(pattern, "."), strings.Split(name, "."))
+}
+
+func matchParts(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				if matchParts(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(patterns[0], names[0]); !ok {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+// Report whether the name contains any special character of rule pattern.
+func IsRulePattern(name string) bool {
+	return strings.ContainsAny(name, `*?[\`)
+}
+
+// Return a rule which sets the level of matching loggers.
+func NewLevelRule(pattern string, level LogLevelType) *LoggerRule {
+	return &LoggerRule{
+		Pattern: pattern,
+		Apply: func(logger Logger) error {
+			return logger.SetLevel(level)
+		},
+	}
+}
+
+// Return a rule which adds the specified handlers to matching loggers.
+func NewHandlerRule(pattern string, handlers ...Handler) *LoggerRule {
+	return &LoggerRule{
+		Pattern: pattern,
+		Apply: func(logger Logger) error {
+			for _, handler := range handlers {
+				logger.AddHandler(handler)
+			}
+			return nil
+		},
+	}
+}
+
+// Parse the comma-separated level rules in the form of "pattern=LEVEL",
+// such as "db.*=DEBUG,http.**=WARN".
+func ParseLevelRules(spec string) ([]*LoggerRule, error) {
+	var rules []*LoggerRule
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New(fmt.Sprintf(
+				"level rule: %s should be in form of pattern=LEVEL", item))
+		}
+		pattern := strings.TrimSpace(parts[0])
+		levelName := strings.ToUpper(strings.TrimSpace(parts[1]))
+		level, ok := GetNameLevel(levelName)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("unknown level: %s", levelName))
+		}
+		if err := ValidateRulePattern(pattern); err != nil {
+			return nil, err
+		}
+		rules = append(rules, NewLevelRule(pattern, level))
+	}
+	return rules, nil
+}
+
+// Add the specified rule to this manager, and apply it to all existing
+// loggers whose names match the pattern. The rule will be applied to every
+// matching logger created later as well. Rules are applied in the order
+// they are added, so a later rule overrides an earlier one.
+// Return the first error when applying the rule to the existing loggers.
+func (self *Manager) AddRule(rule *LoggerRule) error {
+	if err := ValidateRulePattern(rule.Pattern); err != nil {
+		return err
+	}
+	self.ruleLock.Lock()
+	self.rules = append(self.rules, rule)
+	self.ruleLock.Unlock()
+	var result error
+	self.Walk(func(name string, node Node) {
+		if (node == self.root) || (node.Type() != NodeLogger) {
+			return
+		}
+		if MatchRulePattern(rule.Pattern, name) {
+			logger, _ := node.(Logger)
+			if err := rule.Apply(logger); (err != nil) && (result == nil) {
+				result = err
+			}
+		}
+	})
+	return result
+}
+
+// Parse the level rules in spec as ParseLevelRules() does and add them.
+func (self *Manager) AddLevelRules(spec string) error {
+	rules, err := ParseLevelRules(spec)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if err := self.AddRule(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove all rules with the specified pattern. It doesn't revert what
+// the rules have applied to loggers.
+func (self *Manager) RemoveRules(pattern string) {
+	self.ruleLock.Lock()
+	defer self.ruleLock.Unlock()
+	rules := make([]*LoggerRule, 0, len(self.rules))
+	for _, rule := range self.rules {
+		if rule.Pattern != pattern {
+			rules = append(rules, rule)
+		}
+	}
+	self.rules = rules
+}
+
+// Apply all matching rules to the newly created logger.
+// Errors are ignored since there is no way to report them to the caller
+// of GetLogger().
+func (self *Manager) applyRules(logger Logger) {
+	self.ruleLock.RLock()
+	rules := self.rules
+	self.ruleLock.RUnlock()
+	name := logger.GetName()
+	for _, rule := range rules {
+		if MatchRulePattern(rule.Pattern, name) {
+			rule.Apply(logger)
+		}
+	}
+}
+
+// Return a rule which configures matching loggers with the logger config m,
+// as a pattern in the loggers section of config does.
+func newConfigRule(
+	pattern string, m ConfMap, env *ConfEnv) (*LoggerRule, error) {
+
+	// Try the config on a scratch logger first, otherwise any error in it
+	// would be ignored silently for the loggers created later.
+	if err := validateConfigLogger(pattern, m, false, env); err != nil {
+		return nil, err
+	}
+	return &LoggerRule{
+		Pattern: pattern,
+		Apply: func(logger Logger) error {
+			return ConfigLogger(m, logger, false, env)
+		},
+	}, nil
+}
+
+// Return the logger patterns in config, in the order to be applied.
+func configRulePatterns(conf *Conf) []string {
+	patterns := make([]string, 0)
+	for name := range conf.Loggers {
+		if IsRulePattern(name) {
+			patterns = append(patterns, name)
+		}
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// Replace the rules in old with the ones in rules, without applying them
+// to the existing loggers.
+func (self *Manager) replaceRules(old, rules []*LoggerRule) {
+	self.ruleLock.Lock()
+	defer self.ruleLock.Unlock()
+	result := make([]*LoggerRule, 0, len(self.rules)+len(rules))
+	for _, rule := range self.rules {
+		removed := false
+		for _, r := range old {
+			if r == rule {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			result = append(result, rule)
+		}
+	}
+	self.rules = append(result, rules...)
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestMatchRulePattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"db", "db", true},
+		{"db", "db.pool", false},
+		{"db.*", "db.pool", true},
+		{"db.*", "db", false},
+		{"db.*", "db.pool.conn", false},
+		{"db.p*", "db.pool", true},
+		{"db.p*", "db.conn", false},
+		{"http.**", "http", true},
+		{"http.**", "http.server", true},
+		{"http.**", "http.server.conn", true},
+		{"http.**", "https", false},
+		{"**.conn", "db.pool.conn", true},
+		{"**.conn", "conn", true},
+		{"a.**.c", "a.c", true},
+		{"a.**.c", "a.b.b.c", true},
+		{"a.**.c", "a.b.d", false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.match, MatchRulePattern(c.pattern, c.name),
+			"pattern: %s, name: %s", c.pattern, c.name)
+	}
+	require.Nil(t, ValidateRulePattern("db.*"))
+	require.NotNil(t, ValidateRulePattern(""))
+	require.NotNil(t, ValidateRulePattern("db..*"))
+	require.NotNil(t, ValidateRulePattern("db.[a"))
+}
+
+func TestParseLevelRules(t *testing.T) {
+	rules, err := ParseLevelRules("db.*=DEBUG, http.**=warn,")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(rules))
+	require.Equal(t, "db.*", rules[0].Pattern)
+	require.Equal(t, "http.**", rules[1].Pattern)
+	_, err = ParseLevelRules("db.*")
+	require.NotNil(t, err)
+	_, err = ParseLevelRules("db.*=NOSUCHLEVEL")
+	require.NotNil(t, err)
+	_, err = ParseLevelRules("db..x=INFO")
+	require.NotNil(t, err)
+}
+
+func TestManagerAddRule(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	existing := manager.GetLogger("db.pool")
+	other := manager.GetLogger("dbx.pool")
+	require.Nil(t, manager.AddLevelRules("db.*=DEBUG,http.**=ERROR"))
+	require.Equal(t, LevelDebug, existing.GetLevel())
+	require.Equal(t, LevelNotset, other.GetLevel())
+	// placeholder nodes are skipped and get the rule once created
+	require.Equal(t, LevelDebug, manager.GetLogger("db.conn").GetLevel())
+	require.Equal(t, LevelNotset, manager.GetLogger("db").GetLevel())
+	require.Equal(t, LevelError, manager.GetLogger("http").GetLevel())
+	require.Equal(t, LevelError, manager.GetLogger("http.a.b").GetLevel())
+
+	// a later rule overrides an earlier one
+	handler := NewNullHandler()
+	require.Nil(t, manager.AddRule(NewLevelRule("db.conn", LevelInfo)))
+	require.Nil(t, manager.AddRule(NewHandlerRule("db.**", handler)))
+	require.Equal(t, LevelInfo, manager.GetLogger("db.conn").GetLevel())
+	require.Equal(t, []Handler{handler}, existing.GetHandlers())
+	logger := manager.GetLogger("db.x")
+	require.Equal(t, LevelDebug, logger.GetLevel())
+	require.Equal(t, []Handler{handler}, logger.GetHandlers())
+
+	manager.RemoveRules("db.*")
+	require.Equal(t, LevelNotset, manager.GetLogger("db.y").GetLevel())
+	require.NotNil(t, manager.AddRule(NewLevelRule("db.[", LevelInfo)))
+}
+
+func TestDictConfig_LoggerPattern(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	existing := manager.GetLogger("cfg.a")
+	conf := &Conf{
+		Handlers: map[string]ConfMap{
+			"h": {"class": "NullHandler"},
+		},
+		Loggers: map[string]ConfMap{
+			"cfg.*": {
+				"level":    "DEBUG",
+				"handlers": []interface{}{"h"},
+			},
+			"cfg.b": {
+				"level": "ERROR",
+			},
+		},
+	}
+	require.Nil(t, manager.DictConfig(conf))
+	require.Equal(t, LevelDebug, existing.GetLevel())
+	require.Equal(t, 1, len(existing.GetHandlers()))
+	// the explicit logger config wins over the pattern
+	require.Equal(t, LevelError, manager.GetLogger("cfg.b").GetLevel())
+	logger := manager.GetLogger("cfg.c")
+	require.Equal(t, LevelDebug, logger.GetLevel())
+	require.Equal(t, existing.GetHandlers(), logger.GetHandlers())
+	require.Equal(t, LevelNotset, manager.GetLogger("cfg").GetLevel())
+
+	conf = &Conf{
+		Loggers: map[string]ConfMap{
+			"bad.*": {"level": "NOSUCHLEVEL"},
+		},
+	}
+	require.NotNil(t, manager.DictConfig(conf))
+}
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+)
+
+// Action to take on a signal by HandleSignals().
+type SignalAction uint8
+
+const (
+	// Reopen the files of all handlers which support it, e.g. FileHandler
+	// and the rotating file handlers, for external tools like logrotate.
+	SignalReopen SignalAction = 1 + iota
+	// Apply the config file applied last time again, by ReloadConfig().
+	SignalReapplyConfig
+	// Step the level down to the next lower level, for more verbose logs.
+	// The level overrides of the logger are cancelled, so that the level
+	// stepped from the effective one takes effect at once.
+	SignalLevelDown
+	// Step the level up to the next higher level, for less verbose logs,
+	// as SignalLevelDown does.
+	SignalLevelUp
+)
+
+func (action SignalAction) String() string {
+	switch action {
+	case SignalReopen:
+		return "reopen"
+	case SignalReapplyConfig:
+		return "reapply config"
+	case SignalLevelDown:
+		return "level down"
+	case SignalLevelUp:
+		return "level up"
+	default:
+		return fmt.Sprintf("SignalAction(%d)", uint8(action))
+	}
+}
+
+// Handlers which could reopen their underlying files.
+type Reopener interface {
+	Reopen() error
+}
+
+// Options for HandleSignals().
+type SignalOptions struct {
+	// The actions to take on signals. DefaultSignalActions is used if it's
+	// nil.
+	Actions map[os.Signal][]SignalAction
+	// The name of the logger whose level is stepped, the root logger
+	// if it's empty.
+	Logger string
+	// Called with the error if any action fails. The error is logged on
+	// the root logger if it's nil.
+	OnError func(sig os.Signal, err error)
+}
+
+// A signal handler which takes actions on signals for a manager.
+type SignalHandler struct {
+	manager    *Manager
+	opts       SignalOptions
+	signalChan chan os.Signal
+	stopChan   chan struct{}
+	waitGroup  sync.WaitGroup
+}
+
+// Take actions on signals for default manager.
+// See Manager.HandleSignals() for details.
+func HandleSignals(opts *SignalOptions) (*SignalHandler, error) {
+	return manager.HandleSignals(opts)
+}
+
+// Take actions on signals as specified in opts, until the returned signal
+// handler is stopped. It's opt-in, and if opts is nil, the default options
+// are used, i.e. on POSIX systems, SIGHUP reopens files, and SIGUSR1 and
+// SIGUSR2 step the root level down and up.
+func (self *Manager) HandleSignals(
+	opts *SignalOptions) (*SignalHandler, error) {
+
+	if opts == nil {
+		opts = &SignalOptions{}
+	}
+	object := &SignalHandler{
+		manager:  self,
+		opts:     *opts,
+		stopChan: make(chan struct{}),
+	}
+	if object.opts.Actions == nil {
+		object.opts.Actions = DefaultSignalActions
+	}
+	signals := make([]os.Signal, 0, len(object.opts.Actions))
+	for sig, actions := range object.opts.Actions {
+		for _, action := range actions {
+			if (action < SignalReopen) || (action > SignalLevelUp) {
+				return nil, errors.New(fmt.Sprintf(
+					"unknown action: %s for signal: %s", action, sig))
+			}
+		}
+		signals = append(signals, sig)
+	}
+	object.signalChan = make(chan os.Signal, 1)
+	signal.Notify(object.signalChan, signals...)
+	object.waitGroup.Add(1)
+	go object.loop()
+	return object, nil
+}
+
+func (self *SignalHandler) loop() {
+	defer self.waitGroup.Done()
+	for {
+		select {
+		case sig := <-self.signalChan:
+			self.Handle(sig)
+		case <-self.stopChan:
+			return
+		}
+	}
+}
+
+// Take all actions for the specified signal, as if it's received.
+// Return the first error if any action fails.
+func (self *SignalHandler) Handle(sig os.Signal) error {
+	var result error
+	for _, action := range self.opts.Actions[sig] {
+		err := self.Do(action)
+		if err == nil {
+			continue
+		}
+		if self.opts.OnError != nil {
+			self.opts.OnError(sig, err)
+		} else {
+			self.manager.GetRoot().ErrorErr(
+				err, "fail to %s on signal: %s", action, sig)
+		}
+		if result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// Take the specified action.
+func (self *SignalHandler) Do(action SignalAction) error {
+	switch action {
+	case SignalReopen:
+		return self.manager.ReopenHandlers()
+	case SignalReapplyConfig:
+		return self.manager.ReapplyConfigFile()
+	case SignalLevelDown, SignalLevelUp:
+		logger := self.manager.GetLogger(self.opts.Logger)
+		level := StepLevel(logger.GetEffectiveLevel(), action == SignalLevelUp)
+		if err := logger.SetLevel(level); err != nil {
+			return err
+		}
+		self.manager.CancelLevelOverrides(logger)
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("unknown action: %s", action))
+	}
+}
+
+// Stop handling signals.
+func (self *SignalHandler) Stop() {
+	signal.Stop(self.signalChan)
+	close(self.stopChan)
+	self.waitGroup.Wait()
+}
+
+// Reopen the files of all handlers registered to the closer of this manager
+// which support it. Return the first error if any handler fails.
+func (self *Manager) ReopenHandlers() error {
+	var result error
+	for _, handler := range self.closer.GetHandlers() {
+		if reopener, ok := handler.(Reopener); ok {
+			if err := reopener.Reopen(); (err != nil) && (result == nil) {
+				result = err
+			}
+		}
+	}
+	return result
+}
+
+// Return the next registered level higher than the specified level if up is
+// true, or the next lower one otherwise, excluding LevelNotset. The level is
+// returned as it is if there is no such level.
+func StepLevel(level LogLevelType, up bool) LogLevelType {
+	levelLock.RLock()
+	levels := make([]LogLevelType, 0, len(levelToNames))
+	for l := range levelToNames {
+		if l != LevelNotset {
+			levels = append(levels, l)
+		}
+	}
+	levelLock.RUnlock()
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i] < levels[j]
+	})
+	if up {
+		for _, l := range levels {
+			if l > level {
+				return l
+			}
+		}
+	} else {
+		for i := len(levels) - 1; i >= 0; i-- {
+			if levels[i] < level {
+				return levels[i]
+			}
+		}
+	}
+	return level
+}
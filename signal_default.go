@@ -0,0 +1,15 @@
+// +build !windows
+
+package logging
+
+import (
+	"os"
+	"syscall"
+)
+
+// The default actions for HandleSignals().
+var DefaultSignalActions = map[os.Signal][]SignalAction{
+	syscall.SIGHUP:  {SignalReopen},
+	syscall.SIGUSR1: {SignalLevelDown},
+	syscall.SIGUSR2: {SignalLevelUp},
+}
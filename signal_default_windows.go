@@ -0,0 +1,12 @@
+package logging
+
+import (
+	"os"
+	"syscall"
+)
+
+// The default actions for HandleSignals(). There is no SIGUSR1 or SIGUSR2
+// on windows.
+var DefaultSignalActions = map[os.Signal][]SignalAction{
+	syscall.SIGHUP: {SignalReopen},
+}
@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestStepLevel(t *testing.T) {
+	require.Equal(t, LevelDebug, StepLevel(LevelInfo, false))
+	require.Equal(t, LevelWarn, StepLevel(LevelInfo, true))
+	require.Equal(t, LevelTrace, StepLevel(LevelTrace, false))
+	require.Equal(t, LevelFatal, StepLevel(LevelFatal, true))
+	require.Equal(t, LevelInfo, StepLevel(LevelInfo+5, false))
+}
+
+func TestHandleSignals_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "a.log")
+	manager := NewManager(NewRootLogger(LevelInfo))
+	defer manager.Shutdown()
+	handler, err := NewFileHandler(file, os.O_APPEND, 0)
+	require.Nil(t, err)
+	manager.AdoptHandler(handler)
+	logger := manager.GetLogger("reopen")
+	logger.AddHandler(handler)
+
+	signals, err := manager.HandleSignals(&SignalOptions{
+		Actions: map[os.Signal][]SignalAction{
+			syscall.SIGHUP: {SignalReopen},
+		},
+	})
+	require.Nil(t, err)
+	defer signals.Stop()
+	logger.Info("a")
+	require.Nil(t, os.Rename(file, file+".1"))
+	logger.Info("b")
+	require.Nil(t, signals.Handle(syscall.SIGHUP))
+	logger.Info("c")
+	handler.Flush()
+	content, err := ioutil.ReadFile(file + ".1")
+	require.Nil(t, err)
+	require.Equal(t, "a\nb\n", string(content))
+	content, err = ioutil.ReadFile(file)
+	require.Nil(t, err)
+	require.Equal(t, "c\n", string(content))
+}
+
+func TestHandleSignals_Level(t *testing.T) {
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	logger := manager.GetLogger("step")
+	signals, err := manager.HandleSignals(&SignalOptions{
+		Actions: map[os.Signal][]SignalAction{
+			syscall.SIGHUP: {SignalLevelDown, SignalLevelDown},
+		},
+		Logger: "step",
+	})
+	require.Nil(t, err)
+	require.Nil(t, signals.Handle(syscall.SIGHUP))
+	require.Equal(t, LevelDebug, logger.GetLevel())
+	require.Nil(t, signals.Do(SignalLevelUp))
+	require.Equal(t, LevelInfo, logger.GetLevel())
+	require.Equal(t, LevelWarn, manager.GetRoot().GetLevel())
+	// step from the overridden level and cancel the override
+	_, err = logger.SetLevelFor(LevelError, time.Minute)
+	require.Nil(t, err)
+	require.Nil(t, signals.Do(SignalLevelDown))
+	require.Equal(t, LevelWarn, logger.GetLevel())
+	require.Equal(t, LevelWarn, logger.GetEffectiveLevel())
+	require.Equal(t, 0, len(manager.GetLevelOverrides()))
+	signals.Stop()
+
+	var errs []error
+	signals, err = manager.HandleSignals(&SignalOptions{
+		Actions: map[os.Signal][]SignalAction{
+			syscall.SIGHUP: {SignalReapplyConfig},
+		},
+		OnError: func(sig os.Signal, err error) {
+			errs = append(errs, err)
+		},
+	})
+	require.Nil(t, err)
+	defer signals.Stop()
+	require.NotNil(t, signals.Handle(syscall.SIGHUP))
+	require.Equal(t, 1, len(errs))
+
+	_, err = manager.HandleSignals(&SignalOptions{
+		Actions: map[os.Signal][]SignalAction{
+			syscall.SIGHUP: {SignalAction(0)},
+		},
+	})
+	require.NotNil(t, err)
+}
+
+func TestReapplyConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yml")
+	content := "handlers:\n  h:\n    class: NullHandler\n" +
+		"loggers:\n  reapply:\n    level: INFO\n    handlers: [h]\n"
+	require.Nil(t, ioutil.WriteFile(file, []byte(content), 0644))
+	manager := NewManager(NewRootLogger(LevelWarn))
+	defer manager.Shutdown()
+	require.Nil(t, manager.ApplyConfigFile(file))
+	require.Equal(t, file, manager.GetConfigFile())
+	logger := manager.GetLogger("reapply")
+	handlers := logger.GetHandlers()
+
+	content = strings.Replace(content, "INFO", "DEBUG", 1)
+	require.Nil(t, ioutil.WriteFile(file, []byte(content), 0644))
+	require.Nil(t, manager.ReapplyConfigFile())
+	require.Equal(t, LevelDebug, logger.GetLevel())
+	// the unchanged handler is kept rather than duplicated
+	require.Equal(t, handlers, logger.GetHandlers())
+}
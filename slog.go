@@ -0,0 +1,248 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// The attribute key naming the logger which a slog record is routed to.
+	SlogLoggerKey = "logger"
+	// The attribute key of the error of a LogRecord forwarded to slog.
+	SlogErrorKey = "error"
+	// The attribute key of the stack of a LogRecord forwarded to slog.
+	SlogStackKey = "stack"
+)
+
+// Return the slog level corresponding to the specified logging level.
+// A level between two predefined levels is mapped as the lower one.
+func ToSlogLevel(level LogLevelType) slog.Level {
+	switch {
+	case level >= LevelFatal:
+		return slog.LevelError + 4
+	case level >= LevelError:
+		return slog.LevelError
+	case level >= LevelWarn:
+		return slog.LevelWarn
+	case level >= LevelInfo:
+		return slog.LevelInfo
+	case level >= LevelDebug:
+		return slog.LevelDebug
+	default:
+		return slog.LevelDebug - 4
+	}
+}
+
+// Return the logging level corresponding to the specified slog level.
+// A level between two predefined levels is mapped as the lower one.
+func FromSlogLevel(level slog.Level) LogLevelType {
+	switch {
+	case level >= slog.LevelError+4:
+		return LevelFatal
+	case level >= slog.LevelError:
+		return LevelError
+	case level >= slog.LevelWarn:
+		return LevelWarn
+	case level >= slog.LevelInfo:
+		return LevelInfo
+	case level >= slog.LevelDebug:
+		return LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
+// A slog.Handler which converts slog records into LogRecords and handles
+// them with a logger, so that they go through the filters and handlers
+// in the logger hierarchy. The attributes of slog records become
+// the fields of LogRecords, with the keys in groups qualified by
+// the group names, e.g. "group.key". An attribute with key SlogLoggerKey
+// routes the records to the logger of that name in the manager, e.g.
+//     slog.New(logging.NewSlogBridge(root)).With("logger", "a.b")
+// logs to the logger "a.b".
+type SlogBridge struct {
+	logger Logger
+	fields Fields
+	prefix string
+}
+
+// Initialize a slog bridge which logs to the specified logger.
+func NewSlogBridge(logger Logger) *SlogBridge {
+	return &SlogBridge{
+		logger: logger,
+	}
+}
+
+// Return the logger which records are logged to.
+func (self *SlogBridge) GetLogger() Logger {
+	return self.logger
+}
+
+func (self *SlogBridge) Enabled(_ context.Context, level slog.Level) bool {
+	return self.logger.IsEnabledFor(FromSlogLevel(level))
+}
+
+func (self *SlogBridge) Handle(ctx context.Context, r slog.Record) error {
+	logger := self.logger
+	fields := make(Fields, len(self.fields), len(self.fields)+r.NumAttrs())
+	copy(fields, self.fields)
+	var err error
+	r.Attrs(func(attr slog.Attr) bool {
+		if (self.prefix == "") && (attr.Key == SlogLoggerKey) {
+			logger = self.namedLogger(attr.Value)
+			return true
+		}
+		fields = appendSlogAttr(fields, self.prefix, attr)
+		if e, ok := attr.Value.Any().(error); ok && (err == nil) {
+			err = e
+		}
+		return true
+	})
+	// Enabled() checks the level of current logger only, not the one
+	// named by the record.
+	if !logger.IsEnabledFor(FromSlogLevel(r.Level)) {
+		return nil
+	}
+	callerInfo := UnknownCallerInfo
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		callerInfo = &CallerInfo{
+			PathName: frame.File,
+			FileName: filepath.Base(frame.File),
+			LineNo:   uint32(frame.Line),
+			FuncName: frame.Function,
+		}
+	}
+	record := NewLogRecord(
+		logger.GetName(),
+		FromSlogLevel(r.Level),
+		callerInfo.PathName,
+		callerInfo.FileName,
+		callerInfo.LineNo,
+		callerInfo.FuncName,
+		"",
+		false,
+		[]interface{}{r.Message})
+	if !r.Time.IsZero() {
+		record.CreatedTime = r.Time
+	}
+	record.Fields = fields
+	record.Err = err
+	if ctx != nil {
+		logger = logger.Ctx(ctx)
+	}
+	logger.Handle(record)
+	return nil
+}
+
+func (self *SlogBridge) WithAttrs(attrs []slog.Attr) slog.Handler {
+	object := &SlogBridge{
+		logger: self.logger,
+		fields: self.fields.Merge(nil),
+		prefix: self.prefix,
+	}
+	for _, attr := range attrs {
+		if (self.prefix == "") && (attr.Key == SlogLoggerKey) {
+			object.logger = self.namedLogger(attr.Value)
+			continue
+		}
+		object.fields = appendSlogAttr(object.fields, self.prefix, attr)
+	}
+	return object
+}
+
+func (self *SlogBridge) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return self
+	}
+	return &SlogBridge{
+		logger: self.logger,
+		fields: self.fields,
+		prefix: self.prefix + name + ".",
+	}
+}
+
+// Return the logger named by the specified attribute value in the manager
+// of current logger.
+func (self *SlogBridge) namedLogger(value slog.Value) Logger {
+	name := value.Resolve().String()
+	manager := self.logger.GetManager()
+	if (manager == nil) || (name == "") {
+		return self.logger
+	}
+	return manager.GetLogger(name)
+}
+
+// Append the specified slog attribute to fields, with group attributes
+// flattened and their keys qualified by the group names.
+func appendSlogAttr(fields Fields, prefix string, attr slog.Attr) Fields {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix = prefix + attr.Key + "."
+		}
+		for _, a := range value.Group() {
+			fields = appendSlogAttr(fields, prefix, a)
+		}
+		return fields
+	}
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	return append(fields, Field{Key: prefix + attr.Key, Value: value.Any()})
+}
+
+// A handler class which forwards logging records into a slog.Handler.
+// The name of logger, fields, error and stack of records are forwarded
+// as slog attributes.
+type SlogHandler struct {
+	*BaseHandler
+	handler slog.Handler
+}
+
+// Initialize a slog handler which forwards records into the specified
+// slog.Handler.
+func NewSlogHandler(handler slog.Handler) *SlogHandler {
+	object := &SlogHandler{
+		BaseHandler: NewBaseHandler("", LevelNotset),
+		handler:     handler,
+	}
+	Closer.AddHandler(object)
+	return object
+}
+
+// Emit a record.
+// The record is converted into a slog record and handled by the slog.Handler
+// if it's enabled for the record level.
+func (self *SlogHandler) Emit(record *LogRecord) error {
+	ctx := record.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := ToSlogLevel(record.Level)
+	if !self.handler.Enabled(ctx, level) {
+		return nil
+	}
+	r := slog.NewRecord(record.CreatedTime, level, record.GetMessage(), 0)
+	r.AddAttrs(slog.String(SlogLoggerKey, record.Name))
+	for _, field := range record.Fields {
+		r.AddAttrs(slog.Any(field.Key, field.Value))
+	}
+	if record.Err != nil {
+		r.AddAttrs(slog.Any(SlogErrorKey, record.Err))
+	}
+	if len(record.Stack) > 0 {
+		r.AddAttrs(slog.String(SlogStackKey, strings.TrimSpace(record.Stack)))
+	}
+	return self.handler.Handle(ctx, r)
+}
+
+func (self *SlogHandler) Handle(record *LogRecord) int {
+	return self.Handle2(self, record)
+}
@@ -0,0 +1,78 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestSlogBridge(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("slog")
+	logger.SetLevel(LevelInfo)
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+
+	testError := errors.New("test error")
+	slogger := slog.New(NewSlogBridge(logger)).With("a", 1)
+	slogger.Debug("dropped")
+	slogger.WithGroup("g").Warn("message", "b", "x", "err", testError)
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "slog", record.Name)
+	require.Equal(t, LevelWarn, record.Level)
+	require.Equal(t, "message", record.GetMessage())
+	require.Equal(t, NewFields("a", int64(1), "g.b", "x", "g.err", testError),
+		record.Fields)
+	require.Equal(t, testError, record.Err)
+	require.Equal(t, "slog_test.go", record.FileName)
+
+	// route to the named logger in hierarchy
+	slogger.With(SlogLoggerKey, "slog.child").Error("message")
+	record, err = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "slog.child", record.Name)
+	require.Equal(t, LevelError, record.Level)
+
+	// respect the level of the named logger
+	logger.SetLevel(LevelDebug)
+	GetLogger("slog.quiet").SetLevel(LevelError)
+	slogger.Debug("dropped", SlogLoggerKey, "slog.quiet")
+	slogger.Warn("dropped", SlogLoggerKey, "slog.quiet")
+	slogger.Debug("message")
+	record, err = handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "slog", record.Name)
+	require.Equal(t, LevelDebug, record.Level)
+}
+
+func TestSlogHandler(t *testing.T) {
+	defer Shutdown()
+	var buf bytes.Buffer
+	handler := NewSlogHandler(slog.NewTextHandler(
+		&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := GetLogger("slog")
+	logger.SetLevel(LevelDebug)
+	logger.AddHandler(handler)
+	logger.Debug("dropped")
+	logger.With("a", 1).ErrorErr(errors.New("test error"), "message")
+	line := buf.String()
+	require.True(t, strings.HasSuffix(line,
+		"level=ERROR msg=message logger=slog a=1 error=\"test error\"\n"))
+
+	require.Equal(t, LevelInfo, FromSlogLevel(ToSlogLevel(LevelInfo)))
+	require.Equal(t, LevelFatal, FromSlogLevel(ToSlogLevel(LevelFatal)))
+	require.Equal(t, LevelTrace, FromSlogLevel(ToSlogLevel(LevelTrace)))
+	require.Nil(t, handler.Emit(NewLogRecord(
+		"slog", LevelWarn, "", "", 0, "", "", false, nil)))
+	require.True(t, handler.handler.Enabled(context.Background(), slog.LevelWarn))
+}
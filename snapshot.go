@@ -0,0 +1,218 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A snapshot of a handler attached to a logger.
+type HandlerSnapshot struct {
+	Name    string   `json:"name,omitempty"`
+	Class   string   `json:"class"`
+	Level   string   `json:"level"`
+	Filters []string `json:"filters,omitempty"`
+}
+
+// A snapshot of a level override of a logger.
+type OverrideSnapshot struct {
+	ID      uint64 `json:"id"`
+	Level   string `json:"level"`
+	Expires string `json:"expires"`
+}
+
+// A snapshot of a node in the logger hierarchy of manager, along with
+// the snapshots of its children. A PlaceHolder node has only its name
+// and children.
+type LoggerSnapshot struct {
+	Name           string              `json:"name"`
+	PlaceHolder    bool                `json:"placeholder,omitempty"`
+	Level          string              `json:"level,omitempty"`
+	EffectiveLevel string              `json:"effectiveLevel,omitempty"`
+	Propagate      bool                `json:"propagate"`
+	Overrides      []*OverrideSnapshot `json:"overrides,omitempty"`
+	Handlers       []*HandlerSnapshot  `json:"handlers,omitempty"`
+	Filters        []string            `json:"filters,omitempty"`
+	Children       []*LoggerSnapshot   `json:"children,omitempty"`
+}
+
+// Return the description of the specified handler, filter or anything else.
+// It's the result of String() method if there is, otherwise the type name.
+func Describe(i interface{}) string {
+	if s, ok := i.(fmt.Stringer); ok {
+		return s.String()
+	}
+	t := reflect.TypeOf(i)
+	if t == nil {
+		return "nil"
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+type getFiltersable interface {
+	GetFilters() *ListSet
+}
+
+// Return the descriptions of all filters of the specified filterer.
+func describeFilters(i interface{}) []string {
+	filterer, ok := i.(getFiltersable)
+	if !ok {
+		return nil
+	}
+	var result []string
+	for e := filterer.GetFilters().Front(); e != nil; e = e.Next() {
+		result = append(result, Describe(e.Value))
+	}
+	return result
+}
+
+// Take a snapshot of the specified handler.
+func NewHandlerSnapshot(handler Handler) *HandlerSnapshot {
+	return &HandlerSnapshot{
+		Name:    handler.GetName(),
+		Class:   Describe(handler),
+		Level:   GetLevelName(handler.GetLevel()),
+		Filters: describeFilters(handler),
+	}
+}
+
+// Take a snapshot of the specified level override.
+func NewOverrideSnapshot(override *LevelOverride) *OverrideSnapshot {
+	return &OverrideSnapshot{
+		ID:      override.ID,
+		Level:   GetLevelName(override.Level),
+		Expires: override.Expires.Format(time.RFC3339Nano),
+	}
+}
+
+// Take a snapshot of the specified logger, without its children.
+// The level is the one in effect, which is overridden if there are
+// any level overrides.
+func NewLoggerSnapshot(logger Logger) *LoggerSnapshot {
+	handlers := logger.GetHandlers()
+	object := &LoggerSnapshot{
+		Name:           logger.GetName(),
+		Level:          GetLevelName(GetOverriddenLevel(logger)),
+		EffectiveLevel: GetLevelName(logger.GetEffectiveLevel()),
+		Propagate:      logger.GetPropagate(),
+		Filters:        describeFilters(logger),
+	}
+	if manager := logger.GetManager(); manager != nil {
+		for _, override := range manager.GetLevelOverridesOf(logger) {
+			object.Overrides = append(
+				object.Overrides, NewOverrideSnapshot(override))
+		}
+	}
+	for _, handler := range handlers {
+		object.Handlers = append(object.Handlers, NewHandlerSnapshot(handler))
+	}
+	return object
+}
+
+// Call fn on every node in the logger hierarchy, with the root logger first
+// and all other nodes in order of their names. The PlaceHolder nodes are
+// visited as well.
+func (self *Manager) Walk(fn func(name string, node Node)) {
+	self.lock.Lock()
+	names := make([]string, 0, len(self.loggers))
+	nodes := make(map[string]Node, len(self.loggers))
+	for name, node := range self.loggers {
+		names = append(names, name)
+		nodes[name] = node
+	}
+	self.lock.Unlock()
+	sort.Strings(names)
+	fn(self.root.GetName(), self.root)
+	for _, name := range names {
+		fn(name, nodes[name])
+	}
+}
+
+// Take a snapshot of the whole logger hierarchy, and return the snapshot of
+// the root logger. Every node is a child of its nearest ancestor in
+// the hierarchy.
+func (self *Manager) Snapshot() *LoggerSnapshot {
+	var rootSnapshot *LoggerSnapshot
+	snapshots := make(map[string]*LoggerSnapshot)
+	self.Walk(func(name string, node Node) {
+		if node == self.root {
+			rootSnapshot = NewLoggerSnapshot(self.root)
+			return
+		}
+		var snapshot *LoggerSnapshot
+		switch node.Type() {
+		case NodePlaceHolder:
+			snapshot = &LoggerSnapshot{
+				Name:        name,
+				PlaceHolder: true,
+			}
+		case NodeLogger:
+			logger, _ := node.(Logger)
+			snapshot = NewLoggerSnapshot(logger)
+		default:
+			return
+		}
+		snapshots[name] = snapshot
+		// As names are visited in order, the ancestors of a node are always
+		// visited before it.
+		parent := rootSnapshot
+		index := strings.LastIndex(name, ".")
+		for index > 0 {
+			if s, ok := snapshots[name[:index]]; ok {
+				parent = s
+				break
+			}
+			index = strings.LastIndex(name[:index], ".")
+		}
+		parent.Children = append(parent.Children, snapshot)
+	})
+	return rootSnapshot
+}
+
+// Return the text representation of this snapshot as an indented tree.
+func (self *LoggerSnapshot) String() string {
+	var buf bytes.Buffer
+	self.writeText(&buf, "")
+	return buf.String()
+}
+
+func (self *LoggerSnapshot) writeText(buf *bytes.Buffer, indent string) {
+	if self.PlaceHolder {
+		fmt.Fprintf(buf, "%s%s (placeholder)\n", indent, self.Name)
+	} else {
+		fmt.Fprintf(buf, "%s%s level=%s effective=%s propagate=%t\n",
+			indent, self.Name, self.Level, self.EffectiveLevel, self.Propagate)
+	}
+	for _, override := range self.Overrides {
+		fmt.Fprintf(buf, "%s  override %s id=%d expires=%s\n",
+			indent, override.Level, override.ID, override.Expires)
+	}
+	for _, filter := range self.Filters {
+		fmt.Fprintf(buf, "%s  filter %s\n", indent, filter)
+	}
+	for _, handler := range self.Handlers {
+		fmt.Fprintf(buf, "%s  handler %s", indent, handler.Class)
+		if len(handler.Name) > 0 {
+			fmt.Fprintf(buf, " %q", handler.Name)
+		}
+		fmt.Fprintf(buf, " level=%s\n", handler.Level)
+		for _, filter := range handler.Filters {
+			fmt.Fprintf(buf, "%s    filter %s\n", indent, filter)
+		}
+	}
+	for _, child := range self.Children {
+		child.writeText(buf, indent+"  ")
+	}
+}
+
+// Return the JSON representation of this snapshot.
+func (self *LoggerSnapshot) JSON() ([]byte, error) {
+	return json.MarshalIndent(self, "", "  ")
+}
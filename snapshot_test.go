@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestManagerSnapshot(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("snap.a.b")
+	logger.SetLevel(LevelDebug)
+	handler := NewNullHandler()
+	handler.AddFilter(NewNameFilter("snap"))
+	logger.AddHandler(handler)
+	GetLogger("snap").SetPropagate(false)
+
+	snapshot := Snapshot()
+	require.Equal(t, "root", snapshot.Name)
+	require.Equal(t, 1, len(snapshot.Children))
+	s := snapshot.Children[0]
+	require.Equal(t, "snap", s.Name)
+	require.False(t, s.Propagate)
+	require.Equal(t, "WARN", s.EffectiveLevel)
+	require.Equal(t, 1, len(s.Children))
+	s = s.Children[0]
+	require.Equal(t, "snap.a", s.Name)
+	require.True(t, s.PlaceHolder)
+	s = s.Children[0]
+	require.Equal(t, "snap.a.b", s.Name)
+	require.Equal(t, "DEBUG", s.Level)
+	require.Equal(t, "DEBUG", s.EffectiveLevel)
+	require.Equal(t, []*HandlerSnapshot{{
+		Class:   "NullHandler",
+		Level:   "NOTSET",
+		Filters: []string{`NameFilter("snap")`},
+	}}, s.Handlers)
+
+	expected := "root level=WARN effective=WARN propagate=false\n" +
+		"  snap level=NOTSET effective=WARN propagate=false\n" +
+		"    snap.a (placeholder)\n" +
+		"      snap.a.b level=DEBUG effective=DEBUG propagate=true\n" +
+		"        handler NullHandler level=NOTSET\n" +
+		"          filter NameFilter(\"snap\")\n"
+	require.Equal(t, expected, snapshot.String())
+
+	bin, err := snapshot.JSON()
+	require.Nil(t, err)
+	var decoded LoggerSnapshot
+	require.Nil(t, json.Unmarshal(bin, &decoded))
+	require.Equal(t, *snapshot, decoded)
+}
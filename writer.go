@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	thisWriterFileName = "writer.go"
+)
+
+var (
+	thisPackagePath = reflect.TypeOf(LogWriter{}).PkgPath()
+)
+
+// A writer which logs every line written to it as a record on the specified
+// logger. Partial lines are buffered until the trailing newline is written or
+// the writer is closed. It's useful to redirect the output of libraries which
+// write to an io.Writer or a *log.Logger into the logger hierarchy, e.g.
+//     log.SetOutput(logging.GetLogger("std").Writer(logging.LevelInfo))
+//     log.SetFlags(0)
+type LogWriter struct {
+	logger Logger
+	level  LogLevelType
+	buffer bytes.Buffer
+	lock   sync.Mutex
+}
+
+// Initialize a writer which logs lines on logger in the specified level.
+func NewLogWriter(logger Logger, level LogLevelType) *LogWriter {
+	return &LogWriter{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// Write p into the writer, and log all the complete lines buffered.
+// It always consumes all of p without error.
+func (self *LogWriter) Write(p []byte) (int, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.buffer.Write(p)
+	for {
+		index := bytes.IndexByte(self.buffer.Bytes(), '\n')
+		if index < 0 {
+			break
+		}
+		line := string(self.buffer.Next(index + 1))
+		self.emit(strings.TrimSuffix(line[:index], "\r"))
+	}
+	return len(p), nil
+}
+
+// Log the partial line buffered, if any.
+func (self *LogWriter) Close() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.buffer.Len() > 0 {
+		self.emit(self.buffer.String())
+		self.buffer.Reset()
+	}
+	return nil
+}
+
+func (self *LogWriter) emit(line string) {
+	if !self.logger.IsEnabledFor(self.level) {
+		return
+	}
+	callerInfo := findWriterCaller()
+	record := NewLogRecord(
+		self.logger.GetName(),
+		self.level,
+		callerInfo.PathName,
+		callerInfo.FileName,
+		callerInfo.LineNo,
+		callerInfo.FuncName,
+		"",
+		false,
+		[]interface{}{line})
+	self.logger.Handle(record)
+}
+
+// Find the stack frame of the caller which writes to a LogWriter, skipping
+// the frames in this file and the standard packages log and fmt.
+func findWriterCaller() *CallerInfo {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		function := frame.Function
+		fileName := filepath.Base(frame.File)
+		inThisFile := strings.HasPrefix(function, thisPackagePath+".") &&
+			(fileName == thisWriterFileName)
+		if !inThisFile &&
+			!strings.HasPrefix(function, "log.") &&
+			!strings.HasPrefix(function, "fmt.") {
+			return &CallerInfo{
+				PathName: frame.File,
+				FileName: fileName,
+				LineNo:   uint32(frame.Line),
+				FuncName: function,
+			}
+		}
+		if !more {
+			return UnknownCallerInfo
+		}
+	}
+}
+
+// Return a *log.Logger of the standard package log, which logs every line
+// it outputs on the specified logger in the specified level. The returned
+// logger has no prefix nor flag, since the formatter takes care of them.
+func NewStdLogger(logger Logger, level LogLevelType) *log.Logger {
+	return log.New(NewLogWriter(logger, level), "", 0)
+}
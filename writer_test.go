@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hhkbp2/testify/require"
+)
+
+func TestLogWriter(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("writer")
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+
+	writer := logger.With("a", 1).Writer(LevelError)
+	fmt.Fprint(writer, "line1\nli")
+	fmt.Fprint(writer, "ne2\r\nline3")
+	for _, line := range []string{"line1", "line2"} {
+		record, err := handler.GetEmitOnTimeout(time.Second)
+		require.Nil(t, err)
+		require.Equal(t, line, record.GetMessage())
+		require.Equal(t, LevelError, record.Level)
+		require.Equal(t, NewFields("a", 1), record.Fields)
+		require.Equal(t, "writer_test.go", record.FileName)
+	}
+	_, err := handler.GetEmitOnTimeout(time.Millisecond * 10)
+	require.Equal(t, ErrorTimeout, err)
+	require.Nil(t, writer.Close())
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "line3", record.GetMessage())
+
+	// records under the level of logger are dropped
+	logger.Writer(LevelInfo).Write([]byte("dropped\n"))
+	_, err = handler.GetEmitOnTimeout(time.Millisecond * 10)
+	require.Equal(t, ErrorTimeout, err)
+}
+
+func TestNewStdLogger(t *testing.T) {
+	defer Shutdown()
+	logger := GetLogger("writer")
+	handler := NewMockHandler(t)
+	logger.AddHandler(handler)
+
+	stdLogger := NewStdLogger(logger, LevelWarn)
+	stdLogger.Printf("message: %d", 1)
+	record, err := handler.GetEmitOnTimeout(time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "message: 1", record.GetMessage())
+	require.Equal(t, LevelWarn, record.Level)
+	require.Equal(t, "writer_test.go", record.FileName)
+	require.Equal(t, "github.com/hhkbp2/go-logging.TestNewStdLogger",
+		record.FuncName)
+}